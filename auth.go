@@ -0,0 +1,369 @@
+package ron
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// BasicAuthConfig configures BasicAuthMiddleware.
+	BasicAuthConfig struct {
+		// Realm is sent to the browser in the challenge. It defaults to
+		// "Restricted".
+		Realm string
+		// Accounts maps user names to passwords.
+		Accounts map[string]string
+		// Validate checks credentials not found in Accounts, for example
+		// against a database. An error other than a wrong password should
+		// be returned as err, which is rendered as it is.
+		Validate func(ctx context.Context, user, password string) (ok bool, err error)
+	}
+
+	// BearerAuthConfig configures BearerAuthMiddleware.
+	BearerAuthConfig struct {
+		// Validate returns the principal a token belongs to. A plain error
+		// rejects the token with 401; an error with a StatusCode method,
+		// such as an HTTPError, is rendered with its own status.
+		Validate func(ctx context.Context, token string) (principal any, err error)
+		// Header reads the token from a header such as X-API-Key instead of
+		// from an Authorization: Bearer header.
+		Header string
+		// Realm is sent in the challenge of rejected requests.
+		Realm string
+	}
+
+	// HMACAuthConfig configures HMACAuthMiddleware and Sign.
+	HMACAuthConfig struct {
+		// Secrets are the keys a signature may be made with. Sign uses the
+		// first, so a new key can be put first while senders switch to it.
+		Secrets [][]byte
+		// Hash is the hash of the HMAC. It defaults to SHA-256.
+		Hash func() hash.Hash
+		// SignatureHeader holds the hex HMAC. It defaults to X-Signature.
+		SignatureHeader string
+		// TimestampHeader holds the Unix time the request was signed at. It
+		// defaults to X-Timestamp.
+		TimestampHeader string
+		// Tolerance is how far the timestamp may be from the server's
+		// clock. Signatures are remembered until their timestamp is out of
+		// it, so each is accepted once. It defaults to 5 minutes.
+		Tolerance time.Duration
+		// MaxBodySize is the largest body read to check the signature. It
+		// defaults to BindingConfig.MaxBodySize.
+		MaxBodySize int64
+		// Now replaces time.Now, for tests.
+		Now func() time.Time
+	}
+
+	// signatureCache remembers the signatures accepted within the replay
+	// window.
+	signatureCache struct {
+		mu        sync.Mutex
+		seen      map[string]time.Time
+		lastSweep time.Time
+	}
+)
+
+var (
+	errMissingSignature = &HTTPError{Code: http.StatusUnauthorized, Err: errors.New("missing request signature")}
+	errInvalidSignature = &HTTPError{Code: http.StatusUnauthorized, Err: errors.New("invalid request signature")}
+	errExpiredSignature = &HTTPError{Code: http.StatusUnauthorized, Err: errors.New("request signature timestamp outside the allowed window")}
+	errReplayedRequest  = &HTTPError{Code: http.StatusUnauthorized, Err: errors.New("request signature already used")}
+)
+
+// BasicAuthMiddleware requires HTTP Basic authentication with an account
+// from config. Passwords are compared in constant time, and so are the user
+// names, so a response doesn't reveal which accounts exist. The user name
+// is stored as the principal of the request, read with
+// PrincipalFromContext. Other requests are rejected
+// with 401 through the engine's error rendering and a challenge for the
+// realm.
+//
+//	admin := e.GROUP("/admin")
+//	admin.USE(e.BasicAuthMiddleware(ron.BasicAuthConfig{
+//		Realm:    "Admin",
+//		Accounts: map[string]string{"admin": os.Getenv("ADMIN_PASSWORD")},
+//	}))
+func (e *Engine) BasicAuthMiddleware(config BasicAuthConfig) Middleware {
+	challenge := fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, defaultIfEmpty("Restricted", config.Realm))
+
+	type account struct{ user, password [sha256.Size]byte }
+	accounts := make([]account, 0, len(config.Accounts))
+	for user, password := range config.Accounts {
+		accounts = append(accounts, account{sha256.Sum256([]byte(user)), sha256.Sum256([]byte(password))})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			if ok {
+				// Hashing gives equal lengths, and every account is
+				// compared, so the time taken doesn't depend on the input.
+				userHash, passwordHash := sha256.Sum256([]byte(user)), sha256.Sum256([]byte(password))
+				match := 0
+				for _, a := range accounts {
+					match |= subtle.ConstantTimeCompare(userHash[:], a.user[:]) & subtle.ConstantTimeCompare(passwordHash[:], a.password[:])
+				}
+				ok = match == 1
+				if !ok && config.Validate != nil {
+					valid, err := config.Validate(r.Context(), user, password)
+					if err != nil {
+						writeError(w, r, err)
+						return
+					}
+					ok = valid
+				}
+			}
+			if !ok {
+				w.Header().Set("WWW-Authenticate", challenge)
+				writeError(w, r, &HTTPError{Code: http.StatusUnauthorized})
+				return
+			}
+
+			next.ServeHTTP(w, withPrincipal(r, user))
+		})
+	}
+}
+
+// BearerAuthMiddleware requires a token, from an Authorization: Bearer
+// header or from config.Header, that config.Validate accepts. The principal
+// it returns is stored as the principal of the request, for handlers to
+// read with PrincipalFromContext:
+//
+//	user, _ := ron.PrincipalFromContext(ctx).(*User)
+//
+// Requests without a valid token are rejected with 401 through the engine's
+// error rendering. It panics if config.Validate is nil.
+func (e *Engine) BearerAuthMiddleware(config BearerAuthConfig) Middleware {
+	if config.Validate == nil {
+		panic("ron: BearerAuthMiddleware needs a Validate function")
+	}
+	challenge := "Bearer"
+	if config.Realm != "" {
+		challenge = fmt.Sprintf("Bearer realm=%q", config.Realm)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r, config.Header)
+			if token == "" {
+				if config.Header == "" {
+					w.Header().Set("WWW-Authenticate", challenge)
+				}
+				writeError(w, r, &HTTPError{Code: http.StatusUnauthorized})
+				return
+			}
+
+			principal, err := config.Validate(r.Context(), token)
+			if err != nil {
+				var sc interface{ StatusCode() int }
+				if !errors.As(err, &sc) {
+					if config.Header == "" {
+						w.Header().Set("WWW-Authenticate", challenge+`, error="invalid_token"`)
+					}
+					err = &HTTPError{Code: http.StatusUnauthorized}
+				}
+				writeError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, withPrincipal(r, principal))
+		})
+	}
+}
+
+// bearerToken returns the token in header, or in the Authorization header
+// when header is empty.
+func bearerToken(r *http.Request, header string) string {
+	if header != "" {
+		return strings.TrimSpace(r.Header.Get(header))
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// principalKey is the context key of the principal, unexported so only
+// the authentication middlewares can set it.
+type principalKey struct{}
+
+// PrincipalFromContext returns the principal stored by an authentication
+// middleware, or nil. It is the one to trust: the copy kept under the
+// Principal key of the CTX is a convenience that any handler or middleware
+// can overwrite with Set.
+func PrincipalFromContext(ctx context.Context) any {
+	return ctx.Value(principalKey{})
+}
+
+// withPrincipal stores principal in the CTX and in the context of r.
+func withPrincipal(r *http.Request, principal any) *http.Request {
+	if c := FromContext(r.Context()); c != nil {
+		c.Set(Principal, principal)
+	}
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, principal))
+}
+
+// HMACAuthMiddleware verifies that requests, typically webhooks, were
+// signed with one of config.Secrets. The signature is the hex HMAC of the
+// timestamp, the method, the request URI and the hex SHA-256 of the body,
+// joined by newlines, as made by Sign. A request is rejected with 401
+// through the engine's error rendering when its signature is missing or
+// wrong, its timestamp is outside the tolerance, or its signature was
+// already accepted. The body is still there for the handlers to read.
+func (e *Engine) HMACAuthMiddleware(config HMACAuthConfig) Middleware {
+	config = config.withDefaults()
+	if config.MaxBodySize == 0 && e != nil && e.Config != nil {
+		config.MaxBodySize = e.Config.Binding.MaxBodySize
+	}
+	cache := &signatureCache{seen: make(map[string]time.Time)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := config.verify(w, r, cache); err != nil {
+				writeError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Sign signs r with the first of config.Secrets for HMACAuthMiddleware,
+// setting the timestamp and signature headers. The body is read and put
+// back.
+func (config HMACAuthConfig) Sign(r *http.Request) error {
+	if len(config.Secrets) == 0 {
+		return errors.New("ron: HMACAuthConfig has no secrets")
+	}
+	config = config.withDefaults()
+
+	body, err := readBody(nil, r, 0)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(config.Now().Unix(), 10)
+	r.Header.Set(config.TimestampHeader, timestamp)
+	r.Header.Set(config.SignatureHeader, hex.EncodeToString(config.mac(config.Secrets[0], r, timestamp, body)))
+	return nil
+}
+
+func (config HMACAuthConfig) withDefaults() HMACAuthConfig {
+	if config.Hash == nil {
+		config.Hash = sha256.New
+	}
+	config.SignatureHeader = defaultIfEmpty("X-Signature", config.SignatureHeader)
+	config.TimestampHeader = defaultIfEmpty("X-Timestamp", config.TimestampHeader)
+	if config.Tolerance == 0 {
+		config.Tolerance = 5 * time.Minute
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return config
+}
+
+func (config HMACAuthConfig) mac(secret []byte, r *http.Request, timestamp string, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+	m := hmac.New(config.Hash, secret)
+	fmt.Fprintf(m, "%s\n%s\n%s\n%s", timestamp, r.Method, r.URL.RequestURI(), hex.EncodeToString(bodyHash[:]))
+	return m.Sum(nil)
+}
+
+func (config HMACAuthConfig) verify(w http.ResponseWriter, r *http.Request, cache *signatureCache) error {
+	timestamp, header := r.Header.Get(config.TimestampHeader), r.Header.Get(config.SignatureHeader)
+	if timestamp == "" || header == "" {
+		return errMissingSignature
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, "sha256="))
+	if err != nil {
+		return errInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errInvalidSignature
+	}
+	now := config.Now()
+	if age := now.Sub(time.Unix(unix, 0)); age > config.Tolerance || age < -config.Tolerance {
+		return errExpiredSignature
+	}
+
+	body, err := readBody(w, r, config.MaxBodySize)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return bodyTooLarge(maxBytesErr)
+		}
+		return &HTTPError{Code: http.StatusBadRequest, Err: err}
+	}
+
+	valid := false
+	for _, secret := range config.Secrets {
+		if hmac.Equal(signature, config.mac(secret, r, timestamp, body)) {
+			valid = true
+		}
+	}
+	if !valid {
+		return errInvalidSignature
+	}
+	if !cache.add(hex.EncodeToString(signature), now, 2*config.Tolerance) {
+		return errReplayedRequest
+	}
+	return nil
+}
+
+// readBody reads the body of r, up to limit bytes unless limit is zero, and
+// replaces it with a reader over what was read.
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	var body io.Reader = r.Body
+	if limit > 0 {
+		body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	data, err := io.ReadAll(body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// add records signature and reports whether it was new. Signatures are
+// forgotten after ttl, when their timestamp can't be accepted any more.
+func (s *signatureCache) add(signature string, now time.Time, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		for k, expires := range s.seen {
+			if !now.Before(expires) {
+				delete(s.seen, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if expires, ok := s.seen[signature]; ok && now.Before(expires) {
+		return false
+	}
+	s.seen[signature] = now.Add(ttl)
+	return true
+}
@@ -0,0 +1,327 @@
+package ron
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func principalHandler(c *CTX, ctx context.Context) {
+	principal, _ := c.Get(Principal)
+	fromCtx := PrincipalFromContext(ctx)
+	if principal != fromCtx {
+		c.Error(errors.New("principal differs between CTX and context"))
+		return
+	}
+	if ctx.Value(Principal) != nil {
+		c.Error(errors.New("principal stored under a string context key"))
+		return
+	}
+	c.W.Write([]byte(principal.(string)))
+}
+
+func Test_BasicAuthMiddleware(t *testing.T) {
+	config := BasicAuthConfig{
+		Realm:    "Admin",
+		Accounts: map[string]string{"admin": "s3cret", "ops": "hunter2"},
+		Validate: func(ctx context.Context, user, password string) (bool, error) {
+			if user == "broken" {
+				return false, &HTTPError{Code: http.StatusServiceUnavailable}
+			}
+			return user == "db" && password == "pass", nil
+		},
+	}
+
+	tests := map[string]struct {
+		givenUser, givenPassword string
+		givenNoAuth              bool
+		expectedCode             int
+		expectedBody             string
+		expectedChallenge        string
+	}{
+		"valid account":   {givenUser: "admin", givenPassword: "s3cret", expectedCode: http.StatusOK, expectedBody: "admin"},
+		"second account":  {givenUser: "ops", givenPassword: "hunter2", expectedCode: http.StatusOK, expectedBody: "ops"},
+		"validator":       {givenUser: "db", givenPassword: "pass", expectedCode: http.StatusOK, expectedBody: "db"},
+		"wrong password":  {givenUser: "admin", givenPassword: "hunter2", expectedCode: http.StatusUnauthorized, expectedChallenge: `Basic realm="Admin", charset="UTF-8"`},
+		"unknown user":    {givenUser: "nobody", givenPassword: "s3cret", expectedCode: http.StatusUnauthorized, expectedChallenge: `Basic realm="Admin", charset="UTF-8"`},
+		"no credentials":  {givenNoAuth: true, expectedCode: http.StatusUnauthorized, expectedChallenge: `Basic realm="Admin", charset="UTF-8"`},
+		"validator error": {givenUser: "broken", givenPassword: "x", expectedCode: http.StatusServiceUnavailable},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := New()
+			e.USE(e.BasicAuthMiddleware(config))
+			e.GET("/", principalHandler)
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			if !tt.givenNoAuth {
+				req.SetBasicAuth(tt.givenUser, tt.givenPassword)
+			}
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedBody != "" && rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body: %s, Actual: %s", tt.expectedBody, rr.Body.String())
+			}
+			if challenge := rr.Header().Get("WWW-Authenticate"); challenge != tt.expectedChallenge {
+				t.Errorf("Expected WWW-Authenticate: %q, Actual: %q", tt.expectedChallenge, challenge)
+			}
+		})
+	}
+}
+
+func Test_BearerAuthMiddleware(t *testing.T) {
+	validate := func(ctx context.Context, token string) (any, error) {
+		switch token {
+		case "good":
+			return "alice", nil
+		case "revoked":
+			return nil, &HTTPError{Code: http.StatusForbidden}
+		}
+		return nil, errors.New("unknown token")
+	}
+
+	tests := map[string]struct {
+		givenConfig       BearerAuthConfig
+		givenHeaders      map[string]string
+		expectedCode      int
+		expectedBody      string
+		expectedChallenge string
+	}{
+		"valid token": {
+			givenConfig:  BearerAuthConfig{Validate: validate},
+			givenHeaders: map[string]string{"Authorization": "Bearer good"},
+			expectedCode: http.StatusOK,
+			expectedBody: "alice",
+		},
+		"scheme is case insensitive": {
+			givenConfig:  BearerAuthConfig{Validate: validate},
+			givenHeaders: map[string]string{"Authorization": "bearer good"},
+			expectedCode: http.StatusOK,
+			expectedBody: "alice",
+		},
+		"missing token": {
+			givenConfig:       BearerAuthConfig{Validate: validate, Realm: "api"},
+			expectedCode:      http.StatusUnauthorized,
+			expectedChallenge: `Bearer realm="api"`,
+		},
+		"other scheme": {
+			givenConfig:       BearerAuthConfig{Validate: validate},
+			givenHeaders:      map[string]string{"Authorization": "Basic Zm9vOmJhcg=="},
+			expectedCode:      http.StatusUnauthorized,
+			expectedChallenge: "Bearer",
+		},
+		"invalid token": {
+			givenConfig:       BearerAuthConfig{Validate: validate, Realm: "api"},
+			givenHeaders:      map[string]string{"Authorization": "Bearer bad"},
+			expectedCode:      http.StatusUnauthorized,
+			expectedChallenge: `Bearer realm="api", error="invalid_token"`,
+		},
+		"error with status": {
+			givenConfig:  BearerAuthConfig{Validate: validate},
+			givenHeaders: map[string]string{"Authorization": "Bearer revoked"},
+			expectedCode: http.StatusForbidden,
+		},
+		"api key header": {
+			givenConfig:  BearerAuthConfig{Validate: validate, Header: "X-API-Key"},
+			givenHeaders: map[string]string{"X-API-Key": "good"},
+			expectedCode: http.StatusOK,
+			expectedBody: "alice",
+		},
+		"missing api key": {
+			givenConfig:  BearerAuthConfig{Validate: validate, Header: "X-API-Key"},
+			givenHeaders: map[string]string{"Authorization": "Bearer good"},
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := New()
+			e.USE(e.BearerAuthMiddleware(tt.givenConfig))
+			e.GET("/", principalHandler)
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tt.givenHeaders {
+				req.Header.Set(k, v)
+			}
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedBody != "" && rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body: %s, Actual: %s", tt.expectedBody, rr.Body.String())
+			}
+			if challenge := rr.Header().Get("WWW-Authenticate"); challenge != tt.expectedChallenge {
+				t.Errorf("Expected WWW-Authenticate: %q, Actual: %q", tt.expectedChallenge, challenge)
+			}
+		})
+	}
+}
+
+func Test_BearerAuthMiddlewareWithoutValidate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	New().BearerAuthMiddleware(BearerAuthConfig{})
+}
+
+func Test_AuthRejectsAsProblem(t *testing.T) {
+	e := New()
+	e.USE(e.BearerAuthMiddleware(BearerAuthConfig{Validate: func(context.Context, string) (any, error) {
+		return nil, errors.New("unknown token")
+	}}))
+	e.GET("/", principalHandler)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", HeaderJSON)
+	e.ServeHTTP(rr, req)
+
+	if header := rr.Header().Get("Content-Type"); header != HeaderProblemJSON {
+		t.Errorf("Expected Content-Type: %s, Actual: %s", HeaderProblemJSON, header)
+	}
+}
+
+func Test_HMACAuthMiddleware(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	server := HMACAuthConfig{
+		Secrets: [][]byte{[]byte("new-secret"), []byte("old-secret")},
+		Now:     func() time.Time { return now },
+	}
+	sign := func(secret string, at time.Time) func(*http.Request) {
+		return func(r *http.Request) {
+			client := HMACAuthConfig{Secrets: [][]byte{[]byte(secret)}, Now: func() time.Time { return at }}
+			if err := client.Sign(r); err != nil {
+				t.Fatalf("Sign() failed: %v", err)
+			}
+		}
+	}
+
+	tests := map[string]struct {
+		givenSign    func(*http.Request)
+		givenTamper  func(*http.Request)
+		expectedCode int
+		expectedBody string
+	}{
+		"valid signature": {
+			givenSign:    sign("new-secret", now),
+			expectedCode: http.StatusOK,
+			expectedBody: `{"event":"paid"}`,
+		},
+		"previous secret": {
+			givenSign:    sign("old-secret", now.Add(-time.Minute)),
+			expectedCode: http.StatusOK,
+			expectedBody: `{"event":"paid"}`,
+		},
+		"unsigned": {
+			givenSign:    func(*http.Request) {},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "missing request signature\n",
+		},
+		"wrong secret": {
+			givenSign:    sign("guess", now),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "invalid request signature\n",
+		},
+		"tampered body": {
+			givenSign: sign("new-secret", now),
+			givenTamper: func(r *http.Request) {
+				r.Body = io.NopCloser(strings.NewReader(`{"event":"refunded"}`))
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "invalid request signature\n",
+		},
+		"tampered timestamp": {
+			givenSign: sign("new-secret", now),
+			givenTamper: func(r *http.Request) {
+				r.Header.Set("X-Timestamp", "1704110401")
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "invalid request signature\n",
+		},
+		"malformed signature": {
+			givenSign: sign("new-secret", now),
+			givenTamper: func(r *http.Request) {
+				r.Header.Set("X-Signature", "not hex")
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "invalid request signature\n",
+		},
+		"expired": {
+			givenSign:    sign("new-secret", now.Add(-10*time.Minute)),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "request signature timestamp outside the allowed window\n",
+		},
+		"from the future": {
+			givenSign:    sign("new-secret", now.Add(10*time.Minute)),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "request signature timestamp outside the allowed window\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := New()
+			e.USE(e.HMACAuthMiddleware(server))
+			e.POST("/webhook", func(c *CTX, ctx context.Context) {
+				body, _ := io.ReadAll(c.R.Body)
+				c.W.Write(body)
+			})
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/webhook?source=billing", strings.NewReader(`{"event":"paid"}`))
+			tt.givenSign(req)
+			if tt.givenTamper != nil {
+				tt.givenTamper(req)
+			}
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, rr.Code)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body: %q, Actual: %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_HMACAuthMiddlewareReplay(t *testing.T) {
+	config := HMACAuthConfig{Secrets: [][]byte{[]byte("secret")}}
+	e := New()
+	e.USE(e.HMACAuthMiddleware(config))
+	e.POST("/webhook", func(c *CTX, ctx context.Context) {})
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader("payload"))
+	if err := config.Sign(req); err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+	replay := req.Clone(context.Background())
+	replay.Body = io.NopCloser(strings.NewReader("payload"))
+
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code: %d, Actual: %d", http.StatusOK, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	e.ServeHTTP(rr, replay)
+	if rr.Code != http.StatusUnauthorized || rr.Body.String() != "request signature already used\n" {
+		t.Errorf("Expected the replay to be rejected, Actual: %d %q", rr.Code, rr.Body.String())
+	}
+}
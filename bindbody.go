@@ -0,0 +1,247 @@
+package ron
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// BinderFunc decodes a body of a registered media type into v. body is
+// already limited to BindingConfig.MaxBodySize, so it can be read as a
+// stream. Errors are returned as they are, so a binder should wrap them in
+// an HTTPError to choose the status.
+type BinderFunc func(c *CTX, body io.Reader, v any) error
+
+// RegisterBinder makes BindBody and Bind decode bodies of mediaType with
+// fn, replacing any built-in binder for it. It is meant to be called while
+// setting up the engine. For example, newline-delimited JSON can be read
+// one record at a time:
+//
+//	e.RegisterBinder("application/x-ndjson", func(c *ron.CTX, body io.Reader, v any) error {
+//		events := v.(*[]Event)
+//		decoder := json.NewDecoder(body)
+//		for decoder.More() {
+//			var event Event
+//			if err := decoder.Decode(&event); err != nil {
+//				return &ron.HTTPError{Code: http.StatusBadRequest, Err: err}
+//			}
+//			*events = append(*events, event)
+//		}
+//		return nil
+//	})
+func (e *Engine) RegisterBinder(mediaType string, fn BinderFunc) {
+	if e.binders == nil {
+		e.binders = make(map[string]BinderFunc)
+	}
+	e.binders[strings.ToLower(mediaType)] = fn
+}
+
+// BindBody decodes the body into v with the binder for its Content-Type:
+// JSON, XML, a urlencoded or multipart form, plain text or a binder added
+// with RegisterBinder. Any other Content-Type renders as 415.
+func (c *CTX) BindBody(v any) error {
+	contentType := c.R.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	bind := c.bodyBinder(mediaType)
+	if bind == nil {
+		return c.finishBind(v, unsupportedMediaType(contentType))
+	}
+	return c.finishBind(v, bind(v))
+}
+
+// BindXML decodes an XML body into v. The Content-Type must be
+// application/xml, text/xml or end in +xml. Errors render like those of
+// BindJSON.
+func (c *CTX) BindXML(v any) error {
+	contentType := c.R.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !isXMLMediaType(mediaType) {
+		return c.finishBind(v, unsupportedMediaType(contentType))
+	}
+	return c.finishBind(v, c.decodeXML(v))
+}
+
+// BindText reads a text/plain body into v, which must be a *string, a
+// *[]byte or an encoding.TextUnmarshaler, and renders as 415 otherwise.
+// The body must be valid UTF-8.
+func (c *CTX) BindText(v any) error {
+	contentType := c.R.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "text/plain" {
+		return c.finishBind(v, unsupportedMediaType(contentType))
+	}
+	return c.finishBind(v, c.decodeText(v))
+}
+
+// bindBody is the body step of Bind. Unlike BindBody it skips an empty
+// body or one it has no binder for, as the other sources may be enough.
+func (c *CTX) bindBody(v any) error {
+	if c.R.Body == nil || c.R.Body == http.NoBody {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.R.Header.Get("Content-Type"))
+	bind := c.bodyBinder(mediaType)
+	if bind == nil {
+		return nil
+	}
+	if err := bind(v); err != nil && err != errEmptyBody {
+		return err
+	}
+	return nil
+}
+
+// bodyBinder returns the binder for mediaType, or nil if there is none.
+func (c *CTX) bodyBinder(mediaType string) func(any) error {
+	if c.E != nil {
+		if fn, ok := c.E.binders[mediaType]; ok {
+			return func(v any) error {
+				return fn(c, c.limitBody(c.bindingConfig().MaxBodySize), v)
+			}
+		}
+	}
+
+	switch {
+	case isJSONMediaType(mediaType):
+		return c.decodeJSON
+	case isXMLMediaType(mediaType):
+		return c.decodeXML
+	case mediaType == "application/x-www-form-urlencoded":
+		return c.bindPostForm
+	case mediaType == "multipart/form-data":
+		return c.bindMultipart
+	case mediaType == "text/plain":
+		return c.decodeText
+	}
+	return nil
+}
+
+func (c *CTX) bindPostForm(v any) error {
+	if err := c.R.ParseForm(); err != nil {
+		return &HTTPError{Code: http.StatusBadRequest, Err: err}
+	}
+	return formBinding.bind(v, c.R.PostForm, c.bindingConfig(), c.bound)
+}
+
+func (c *CTX) bindMultipart(v any) error {
+	if err := c.parseMultipartForm(); err != nil {
+		return err
+	}
+	return formBinding.bindFiles(v, c.R.MultipartForm.Value, c.R.MultipartForm.File, c.bindingConfig(), c.bound)
+}
+
+func isXMLMediaType(mediaType string) bool {
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// decodeXML decodes exactly one XML element from the body. Whitespace,
+// comments and processing instructions may follow it.
+func (c *CTX) decodeXML(v any) error {
+	if c.R.Body == nil || c.R.Body == http.NoBody {
+		return errEmptyBody
+	}
+
+	decoder := xml.NewDecoder(c.limitBody(c.bindingConfig().MaxBodySize))
+	if err := decoder.Decode(v); err != nil {
+		if err == io.EOF {
+			return errEmptyBody
+		}
+		return xmlError(err)
+	}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return xmlError(err)
+		}
+		switch t := token.(type) {
+		case xml.Comment, xml.ProcInst:
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return &HTTPError{Code: http.StatusBadRequest, Err: errors.New("unexpected data after the XML element")}
+			}
+		default:
+			return &HTTPError{Code: http.StatusBadRequest, Err: errors.New("unexpected data after the XML element")}
+		}
+	}
+}
+
+func xmlError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *xml.SyntaxError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return bodyTooLarge(maxBytesErr)
+	case errors.As(err, &syntaxErr):
+		return &HTTPError{
+			Code: http.StatusBadRequest,
+			Err:  fmt.Errorf("malformed XML on line %d: %w", syntaxErr.Line, err),
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &HTTPError{Code: http.StatusBadRequest, Err: errors.New("malformed XML: unexpected end of body")}
+	}
+	return &HTTPError{Code: http.StatusBadRequest, Err: err}
+}
+
+func (c *CTX) decodeText(v any) error {
+	if c.R.Body == nil || c.R.Body == http.NoBody {
+		return errEmptyBody
+	}
+
+	body, err := io.ReadAll(c.limitBody(c.bindingConfig().MaxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return bodyTooLarge(maxBytesErr)
+		}
+		return &HTTPError{Code: http.StatusBadRequest, Err: err}
+	}
+	if !utf8.Valid(body) {
+		return &HTTPError{Code: http.StatusBadRequest, Err: errors.New("text body is not valid UTF-8")}
+	}
+
+	switch target := v.(type) {
+	case *string:
+		*target = string(body)
+	case *[]byte:
+		*target = body
+	case encoding.TextUnmarshaler:
+		if err := target.UnmarshalText(body); err != nil {
+			return &HTTPError{Code: http.StatusBadRequest, Err: err}
+		}
+	default:
+		return &HTTPError{Code: http.StatusUnsupportedMediaType, Err: fmt.Errorf("a text body can't be bound to %T", v)}
+	}
+	return nil
+}
+
+// limitBody caps what can be read from the body at limit bytes, unless
+// limit is zero, and returns the body.
+func (c *CTX) limitBody(limit int64) io.Reader {
+	if limit > 0 {
+		c.R.Body = http.MaxBytesReader(c.W, c.R.Body, limit)
+	}
+	return c.R.Body
+}
+
+func bodyTooLarge(err *http.MaxBytesError) error {
+	return &HTTPError{
+		Code: http.StatusRequestEntityTooLarge,
+		Err:  fmt.Errorf("request body larger than %d bytes", err.Limit),
+	}
+}
+
+func unsupportedMediaType(contentType string) error {
+	return &HTTPError{
+		Code: http.StatusUnsupportedMediaType,
+		Err:  fmt.Errorf("%w: Content-Type %q", http.ErrNotSupported, contentType),
+	}
+}
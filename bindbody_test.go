@@ -0,0 +1,245 @@
+package ron
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type Contact struct {
+	Name  string `json:"name" xml:"name" form:"name"`
+	Email string `json:"email" xml:"email,attr" form:"email"`
+}
+
+func Test_BindXML(t *testing.T) {
+	tests := map[string]struct {
+		contentType  string
+		body         string
+		maxBodySize  int64
+		expected     Contact
+		expectedCode int
+	}{
+		"valid": {
+			contentType: "application/xml",
+			body:        `<?xml version="1.0"?><contact email="foo@example.com"><name>Foo</name></contact>`,
+			expected:    Contact{Name: "Foo", Email: "foo@example.com"},
+		},
+		"text xml with charset": {
+			contentType: "text/xml; charset=utf-8",
+			body:        "<contact><name>Foo</name></contact>\n<!-- sent by partner -->\n",
+			expected:    Contact{Name: "Foo"},
+		},
+		"xml suffix": {
+			contentType: "application/atom+xml",
+			body:        `<contact><name>Foo</name></contact>`,
+			expected:    Contact{Name: "Foo"},
+		},
+		"wrong content type": {
+			contentType:  "application/json",
+			body:         `<contact/>`,
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		"empty body": {
+			contentType:  "application/xml",
+			expectedCode: http.StatusBadRequest,
+		},
+		"malformed": {
+			contentType:  "application/xml",
+			body:         `<contact><name>Foo</contact>`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"trailing element": {
+			contentType:  "application/xml",
+			body:         `<contact/><contact/>`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"too large": {
+			contentType:  "application/xml",
+			body:         `<contact><name>` + strings.Repeat("a", 64) + `</name></contact>`,
+			maxBodySize:  32,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			c := &CTX{R: req, W: newResponseWriter(httptest.NewRecorder()), E: New(func(e *Engine) {
+				if tt.maxBodySize > 0 {
+					e.Config.Binding.MaxBodySize = tt.maxBodySize
+				}
+			})}
+
+			var actual Contact
+			err := c.BindXML(&actual)
+			if tt.expectedCode != 0 {
+				if code := StatusCode(err); err == nil || code != tt.expectedCode {
+					t.Errorf("Expected status code: %d, Actual: %d (%v)", tt.expectedCode, code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindXML() failed: %v", err)
+			}
+			if actual != tt.expected {
+				t.Errorf("Expected: %+v, Actual: %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_BindText(t *testing.T) {
+	tests := map[string]struct {
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		"plain text": {
+			contentType: "text/plain; charset=utf-8",
+			body:        "ping ✓",
+		},
+		"wrong content type": {
+			contentType:  "application/octet-stream",
+			body:         "ping",
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		"invalid utf-8": {
+			contentType:  "text/plain",
+			body:         "\xff\xfe",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			c := &CTX{R: req, E: New()}
+
+			var actual string
+			err := c.BindText(&actual)
+			if tt.expectedCode != 0 {
+				if code := StatusCode(err); err == nil || code != tt.expectedCode {
+					t.Errorf("Expected status code: %d, Actual: %d (%v)", tt.expectedCode, code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindText() failed: %v", err)
+			}
+			if actual != tt.body {
+				t.Errorf("Expected: %q, Actual: %q", tt.body, actual)
+			}
+		})
+	}
+}
+
+func Test_BindBody(t *testing.T) {
+	multipartBody := func() (string, io.Reader) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		mw.WriteField("name", "Foo")
+		mw.WriteField("email", "foo@example.com")
+		mw.Close()
+		return mw.FormDataContentType(), &buf
+	}
+	multipartType, multipartReader := multipartBody()
+
+	tests := map[string]struct {
+		contentType  string
+		body         io.Reader
+		expectedCode int
+	}{
+		"json": {
+			contentType: "application/json; charset=utf-8",
+			body:        strings.NewReader(`{"name":"Foo","email":"foo@example.com"}`),
+		},
+		"xml": {
+			contentType: "application/xml",
+			body:        strings.NewReader(`<contact email="foo@example.com"><name>Foo</name></contact>`),
+		},
+		"urlencoded": {
+			contentType: "application/x-www-form-urlencoded",
+			body:        strings.NewReader("name=Foo&email=foo%40example.com"),
+		},
+		"multipart": {
+			contentType: multipartType,
+			body:        multipartReader,
+		},
+		"unsupported": {
+			contentType:  "application/octet-stream",
+			body:         strings.NewReader("Foo"),
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		"missing content type": {
+			body:         strings.NewReader("Foo"),
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		"text into a struct": {
+			contentType:  "text/plain",
+			body:         strings.NewReader("Foo"),
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", tt.body)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			c := &CTX{R: req, E: New()}
+
+			var actual Contact
+			err := c.BindBody(&actual)
+			if tt.expectedCode != 0 {
+				if code := StatusCode(err); err == nil || code != tt.expectedCode {
+					t.Errorf("Expected status code: %d, Actual: %d (%v)", tt.expectedCode, code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BindBody() failed: %v", err)
+			}
+			expected := Contact{Name: "Foo", Email: "foo@example.com"}
+			if actual != expected {
+				t.Errorf("Expected: %+v, Actual: %+v", expected, actual)
+			}
+		})
+	}
+}
+
+func Test_RegisterBinder(t *testing.T) {
+	e := New()
+	e.RegisterBinder("application/x-ndjson", func(c *CTX, body io.Reader, v any) error {
+		contacts := v.(*[]Contact)
+		decoder := json.NewDecoder(body)
+		for decoder.More() {
+			var contact Contact
+			if err := decoder.Decode(&contact); err != nil {
+				return &HTTPError{Code: http.StatusBadRequest, Err: err}
+			}
+			*contacts = append(*contacts, contact)
+		}
+		return nil
+	})
+
+	body := `{"name":"Foo"}` + "\n" + `{"name":"Bar"}` + "\n"
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	c := &CTX{R: req, E: e}
+
+	var contacts []Contact
+	if err := c.BindBody(&contacts); err != nil {
+		t.Fatalf("BindBody() failed: %v", err)
+	}
+	if len(contacts) != 2 || contacts[0].Name != "Foo" || contacts[1].Name != "Bar" {
+		t.Errorf("Expected Foo and Bar, Actual: %+v", contacts)
+	}
+}
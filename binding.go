@@ -1,129 +1,961 @@
 package ron
 
 import (
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
-func (c *CTX) BindJSON(v any) error {
-	if c.R.Header.Get("Content-Type") != "application/json" {
-		return http.ErrNotSupported
+// binding describes how struct fields are matched against one source of
+// request values.
+type binding struct {
+	// tag is the struct tag holding the key of each field.
+	tag string
+	// nameFallback binds untagged fields using their Go name.
+	nameFallback bool
+	// key normalizes the tag value before the lookup.
+	key func(string) string
+}
+
+var (
+	formBinding   = binding{tag: "form", nameFallback: true}
+	queryBinding  = binding{tag: "query"}
+	pathBinding   = binding{tag: "path"}
+	headerBinding = binding{tag: "header", key: http.CanonicalHeaderKey}
+	cookieBinding = binding{tag: "cookie"}
+)
+
+var (
+	errBindTarget      = errors.New("v must be a pointer to a struct")
+	errUnsupportedType = errors.New("unsupported type")
+)
+
+type (
+	// BindingError describes one request value that couldn't be bound.
+	BindingError struct {
+		// Source is the tag of the binder, such as form or query.
+		Source string
+		// Field is the Go path of the field, such as Items[0].Qty.
+		Field string
+		// Key is the same path built from the request keys, such as
+		// items[0].qty.
+		Key string
+		// Value is the rejected value.
+		Value string
+		// Reason is a short message fit to show next to the input.
+		Reason string
+		Err    error
 	}
-	decoder := json.NewDecoder(c.R.Body)
-	return decoder.Decode(v)
+
+	// BindingErrors lists every value a bind rejected. It renders as 400
+	// Bad Request.
+	BindingErrors []BindingError
+)
+
+func (be BindingError) Error() string {
+	return fmt.Sprintf("invalid %s value %q for %s: %s", be.Source, be.Value, be.Key, be.Reason)
+}
+
+func (be BindingError) Unwrap() error {
+	return be.Err
+}
+
+func (be BindingErrors) Error() string {
+	msgs := make([]string, len(be))
+	for i, e := range be {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (be BindingErrors) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// BindingConfig limits what a request can make the binders allocate.
+type BindingConfig struct {
+	// MaxDepth is the maximum number of segments in a nested key such as
+	// items[0].address.city. Zero uses the default of 10.
+	MaxDepth int
+	// MaxElements is the maximum length of a bound slice, including the
+	// highest index accepted in items[i], and of a bound map. It also caps
+	// the elements of every slice and map bound by one call together, so
+	// nested keys such as a[999][999] can't multiply it. Zero uses the
+	// default of 1000.
+	MaxElements int
+	// Validate runs Validate on the struct after every successful bind.
+	Validate bool
+	// JSON configures how JSON bodies are decoded.
+	JSON JSONBindingConfig
+	// MaxBodySize is the maximum size in bytes of an XML or text body, or
+	// of one read by a binder added with RegisterBinder. Zero disables the
+	// limit.
+	MaxBodySize int64
+}
+
+// JSONBindingConfig configures the decoding of JSON bodies.
+type JSONBindingConfig struct {
+	// DisallowUnknownFields rejects objects with keys that match no field.
+	DisallowUnknownFields bool
+	// UseNumber decodes numbers into interface{} fields as json.Number
+	// instead of float64.
+	UseNumber bool
+	// MaxBodySize is the maximum size of the body in bytes. Zero disables
+	// the limit.
+	MaxBodySize int64
+}
+
+func defaultBindingConfig() BindingConfig {
+	return BindingConfig{
+		MaxDepth:    10,
+		MaxElements: 1000,
+		MaxBodySize: 1 << 20,
+		JSON: JSONBindingConfig{
+			MaxBodySize: 1 << 20,
+		},
+	}
+}
+
+func (c *CTX) bindingConfig() BindingConfig {
+	if c.E == nil || c.E.Config == nil {
+		return defaultBindingConfig()
+	}
+	return c.E.Config.Binding.withDefaults()
+}
+
+// withDefaults fills the limits left at zero. The body sizes are kept, as
+// zero disables those.
+func (config BindingConfig) withDefaults() BindingConfig {
+	defaults := defaultBindingConfig()
+	if config.MaxDepth == 0 {
+		config.MaxDepth = defaults.MaxDepth
+	}
+	if config.MaxElements == 0 {
+		config.MaxElements = defaults.MaxElements
+	}
+	return config
+}
+
+// BindJSON decodes a JSON body into v. The Content-Type must be
+// application/json or end in +json, such as application/problem+json.
+// Errors render as 415 for another Content-Type, 413 for a body over
+// JSONBindingConfig.MaxBodySize and 400 for malformed JSON, trailing data
+// after the value, unknown fields in strict mode or values of the wrong type.
+func (c *CTX) BindJSON(v any) error {
+	return c.finishBind(v, c.bindJSON(v))
 }
 
 func (c *CTX) BindForm(v interface{}) error {
-	if err := c.R.ParseForm(); err != nil {
+	return c.finishBind(v, c.bindForm(v))
+}
+
+// BindQuery fills the fields tagged with `query:"key"` from the query string.
+func (c *CTX) BindQuery(v any) error {
+	return c.finishBind(v, c.bindQuery(v))
+}
+
+// BindPath fills the fields tagged with `path:"name"` from the wildcards of
+// the matched route pattern.
+func (c *CTX) BindPath(v any) error {
+	return c.finishBind(v, c.bindPath(v))
+}
+
+// BindHeader fills the fields tagged with `header:"Name"` from the request
+// headers. Names are matched case-insensitively.
+func (c *CTX) BindHeader(v any) error {
+	return c.finishBind(v, c.bindHeader(v))
+}
+
+// BindCookie fills the fields tagged with `cookie:"name"` from the request
+// cookies.
+func (c *CTX) BindCookie(v any) error {
+	return c.finishBind(v, c.bindCookie(v))
+}
+
+// Bind fills v from every source of the request. Sources are applied from
+// the lowest to the highest precedence, so a later one overwrites a field
+// already set by an earlier one:
+//
+//  1. cookies (`cookie:` tags)
+//  2. headers (`header:` tags)
+//  3. query string (`query:` tags)
+//  4. body, picked by BindBody from the Content-Type: JSON (`json:` tags),
+//     XML (`xml:` tags), a form (`form:` tags) or a registered binder
+//  5. path wildcards (`path:` tags)
+//
+// `default:` tags are applied last, to the fields no source set that are
+// still zero. Values that don't parse in any source are reported together
+// as BindingErrors.
+func (c *CTX) Bind(v any) error {
+	if err := checkBindTarget(v); err != nil {
+		return err
+	}
+	bound := make(map[string]bool)
+	c.bound = bound
+	defer func() { c.bound = nil }()
+
+	binders := []func(any) error{
+		c.bindCookie,
+		c.bindHeader,
+		c.bindQuery,
+		c.bindBody,
+		c.bindPath,
+	}
+	var errs BindingErrors
+	for _, bind := range binders {
+		err := bind(v)
+		var be BindingErrors
+		if errors.As(err, &be) {
+			errs = append(errs, be...)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	val, config := reflect.ValueOf(v).Elem(), c.bindingConfig()
+	for _, b := range []binding{cookieBinding, headerBinding, queryBinding, formBinding, pathBinding} {
+		s := &bindState{source: b.tag, config: config, elements: config.MaxElements, bound: bound}
+		if err := b.setDefaults(val, "", "", s); err != nil {
+			return err
+		}
+		errs = append(errs, s.errs...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return c.finishBind(v, nil)
+}
+
+// finishBind validates v after a successful bind when the engine asks for
+// it.
+func (c *CTX) finishBind(v any, err error) error {
+	if err != nil || !c.bindingConfig().Validate {
+		return err
+	}
+	return Validate(v)
+}
+
+var errEmptyBody = &HTTPError{Code: http.StatusBadRequest, Err: errors.New("empty request body")}
+
+func (c *CTX) bindJSON(v any) error {
+	contentType := c.R.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !isJSONMediaType(mediaType) {
+		return unsupportedMediaType(contentType)
+	}
+	return c.decodeJSON(v)
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// decodeJSON decodes exactly one JSON value from the body, within the
+// configured size limit.
+func (c *CTX) decodeJSON(v any) error {
+	if c.R.Body == nil || c.R.Body == http.NoBody {
+		return errEmptyBody
+	}
+
+	config := c.bindingConfig().JSON
+	decoder := json.NewDecoder(c.limitBody(config.MaxBodySize))
+	if config.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if config.UseNumber {
+		decoder.UseNumber()
+	}
+
+	if err := decoder.Decode(v); err != nil {
+		if err == io.EOF {
+			return errEmptyBody
+		}
+		return jsonError(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err != nil {
+			return jsonError(err)
+		}
+		return &HTTPError{Code: http.StatusBadRequest, Err: errors.New("unexpected data after the JSON value")}
+	}
+	return nil
+}
+
+// jsonError maps a decoding error to the status it should render with.
+// Fields of the wrong type become BindingErrors, like form values.
+func jsonError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var invalidErr *json.InvalidUnmarshalError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return bodyTooLarge(maxBytesErr)
+	case errors.As(err, &syntaxErr):
+		return &HTTPError{
+			Code: http.StatusBadRequest,
+			Err:  fmt.Errorf("malformed JSON at offset %d: %w", syntaxErr.Offset, err),
+		}
+	case errors.As(err, &typeErr):
+		return BindingErrors{{
+			Source: "json",
+			Field:  typeErr.Field,
+			Key:    typeErr.Field,
+			Value:  typeErr.Value,
+			Reason: "must be " + jsonTypeName(typeErr.Type),
+			Err:    err,
+		}}
+	case errors.As(err, &invalidErr):
 		return err
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &HTTPError{Code: http.StatusBadRequest, Err: errors.New("malformed JSON: unexpected end of body")}
+	}
+	return &HTTPError{Code: http.StatusBadRequest, Err: err}
+}
+
+// jsonTypeName names a Go type the way a JSON client would see it.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a " + t.String()
+}
 
+func (c *CTX) bindForm(v any) error {
+	if err := c.parseMultipartForm(); err != nil {
+		return err
+	}
+	return formBinding.bindFiles(v, c.R.Form, c.multipartFiles(), c.bindingConfig(), c.bound)
+}
+
+// multipartFiles returns the uploaded files, if the body was multipart.
+func (c *CTX) multipartFiles() map[string][]*multipart.FileHeader {
+	if c.R.MultipartForm == nil {
+		return nil
+	}
+	return c.R.MultipartForm.File
+}
+
+func (c *CTX) bindQuery(v any) error {
+	return queryBinding.bind(v, c.queryValues(), c.bindingConfig(), c.bound)
+}
+
+func (c *CTX) bindPath(v any) error {
+	if err := checkBindTarget(v); err != nil {
+		return err
+	}
+	return pathBinding.bind(v, c.pathValues(reflect.TypeOf(v).Elem()), c.bindingConfig(), c.bound)
+}
+
+func (c *CTX) bindHeader(v any) error {
+	return headerBinding.bind(v, c.R.Header, c.bindingConfig(), c.bound)
+}
+
+func (c *CTX) bindCookie(v any) error {
+	values := make(map[string][]string)
+	for _, cookie := range c.R.Cookies() {
+		values[cookie.Name] = append(values[cookie.Name], cookie.Value)
+	}
+	return cookieBinding.bind(v, values, c.bindingConfig(), c.bound)
+}
+
+// pathValues collects the wildcards named by the path tags of t, since the
+// request has no way to list them.
+func (c *CTX) pathValues(t reflect.Type) map[string][]string {
+	values := make(map[string][]string)
+	for _, fp := range pathBinding.plan(t).fields {
+		if value := c.R.PathValue(fp.key); value != "" {
+			values[fp.key] = []string{value}
+		}
+	}
+	return values
+}
+
+func checkBindTarget(v any) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
-		return errors.New("v must be a pointer to a struct")
+		return errBindTarget
+	}
+	return nil
+}
+
+// bindState is threaded through one bind call and collects the values that
+// couldn't be bound, so a request reports all of them at once.
+type bindState struct {
+	source string
+	config BindingConfig
+	errs   BindingErrors
+	// elements is what is left of the MaxElements budget of the call.
+	elements int
+	// bound holds the Go paths of the fields a source set, whose defaults
+	// are then skipped. Bind shares it between its sources.
+	bound map[string]bool
+	// files holds uploaded files by their normalized key.
+	files map[string][]*multipart.FileHeader
+}
+
+// check records err, a parse error, and returns nil. An unsupported type
+// isn't the client's fault, so it is returned instead.
+func (s *bindState) check(err error, fieldPath, keyPath, value string, typ reflect.Type) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, errUnsupportedType) {
+		return err
+	}
+	s.fail(fieldPath, keyPath, value, typ, err)
+	return nil
+}
+
+// allocate takes n elements from the budget shared by every slice and map
+// of the call. Once it is spent, the error is recorded and false returned.
+func (s *bindState) allocate(n int, fieldPath, keyPath string, typ reflect.Type) bool {
+	if n > s.elements {
+		s.fail(fieldPath, keyPath, "", typ, fmt.Errorf("too many elements in the request, maximum %d", s.config.MaxElements))
+		return false
 	}
+	s.elements -= n
+	return true
+}
+
+func (s *bindState) fail(fieldPath, keyPath, value string, typ reflect.Type, err error) {
+	s.errs = append(s.errs, BindingError{
+		Source: s.source,
+		Field:  fieldPath,
+		Key:    keyPath,
+		Value:  value,
+		Reason: bindReason(typ, err),
+		Err:    err,
+	})
+}
+
+// bind fills v from values. Within Bind, bound collects the fields set so
+// Bind can apply the defaults once every source is done; with a nil bound
+// the defaults are applied here.
+func (b binding) bind(v any, values map[string][]string, config BindingConfig, bound map[string]bool) error {
+	return b.bindFiles(v, values, nil, config, bound)
+}
 
-	return mapForm(v, c.R.Form)
+// bindFiles is bind for multipart bodies, where *multipart.FileHeader and
+// []*multipart.FileHeader fields are filled from files.
+func (b binding) bindFiles(v any, values map[string][]string, files map[string][]*multipart.FileHeader, config BindingConfig, bound map[string]bool) error {
+	if err := checkBindTarget(v); err != nil {
+		return err
+	}
+	s := &bindState{source: b.tag, config: config, elements: config.MaxElements, bound: bound}
+	if s.bound == nil {
+		s.bound = make(map[string]bool)
+	}
+	if len(files) > 0 {
+		s.files = make(map[string][]*multipart.FileHeader, len(files))
+		for key, fhs := range files {
+			s.files[formPath(key)] = fhs
+		}
+	}
+	root := newFormTree(values, s)
+	val := reflect.ValueOf(v).Elem()
+	if err := b.mapValues(val, root, "", "", s); err != nil {
+		return err
+	}
+	if bound == nil {
+		if err := b.setDefaults(val, "", "", s); err != nil {
+			return err
+		}
+	}
+	if len(s.errs) > 0 {
+		return s.errs
+	}
+	return nil
 }
 
-func mapForm(ptr interface{}, form map[string][]string) error {
-	val := reflect.ValueOf(ptr).Elem()
-	typ := val.Type()
+func (b binding) mapValues(val reflect.Value, node *formNode, fieldPath, keyPath string, s *bindState) error {
+	fields := b.plan(val.Type()).fields
+	for i := range fields {
+		fp := &fields[i]
+		field := val.FieldByIndex(fp.index)
 
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		structField := typ.Field(i)
+		fieldPath, keyPath := joinPath(fieldPath, fp.name), joinPath(keyPath, fp.key)
 
-		if !field.CanSet() {
+		if fp.file {
+			if files := s.files[formPath(keyPath)]; len(files) > 0 {
+				setFiles(field, files)
+				s.bound[fieldPath] = true
+			}
 			continue
 		}
 
-		tag := structField.Tag.Get("form")
-		if tag == "" {
-			tag = structField.Name
+		child := node.lookup(fp.key)
+		if child == nil {
+			continue
+		}
+		s.bound[fieldPath] = true
+		if err := b.setPlanned(field, fp, child, fieldPath, keyPath, s); err != nil {
+			return err
 		}
+	}
+	return nil
+}
+
+// setDefaults applies the `default:` tags of the fields of val that no
+// source set and that are still zero, including those of nested structs.
+func (b binding) setDefaults(val reflect.Value, fieldPath, keyPath string, s *bindState) error {
+	fields := b.plan(val.Type()).fields
+	for i := range fields {
+		fp := &fields[i]
+		field := val.FieldByIndex(fp.index)
+		fieldPath, keyPath := joinPath(fieldPath, fp.name), joinPath(keyPath, fp.key)
 
-		if field.Kind() == reflect.Struct && structField.Anonymous {
-			if err := mapForm(field.Addr().Interface(), form); err != nil {
+		if fp.file {
+			continue
+		}
+		if fp.def == nil {
+			if err := b.setNestedDefaults(field, fieldPath, keyPath, s); err != nil {
 				return err
 			}
 			continue
 		}
+		if s.bound[fieldPath] || !field.IsZero() {
+			continue
+		}
+		if err := b.setPlanned(field, fp, fp.def, fieldPath, keyPath, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		if values, ok := form[tag]; ok && len(values) > 0 {
-			if field.Kind() == reflect.Slice {
-				elemType := field.Type().Elem()
-				slice := reflect.MakeSlice(field.Type(), len(values), len(values))
-				for i, v := range values {
-					elem := slice.Index(i)
-					if elem.Kind() == reflect.Ptr {
-						elem.Set(reflect.New(elemType.Elem()))
-						elem = elem.Elem()
-					}
-					if err := setField(elem, v); err != nil {
-						return err
-					}
-				}
-				field.Set(slice)
-			} else {
-				if err := setField(field, values[0]); err != nil {
-					return err
-				}
+// setNestedDefaults walks into the structs held by field, directly, through
+// a pointer or as the elements of a slice.
+func (b binding) setNestedDefaults(field reflect.Value, fieldPath, keyPath string, s *bindState) error {
+	if isScalarType(field.Type()) {
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.IsNil() {
+			return nil
+		}
+		return b.setNestedDefaults(field.Elem(), fieldPath, keyPath, s)
+	case reflect.Struct:
+		return b.setDefaults(field, fieldPath, keyPath, s)
+	case reflect.Slice:
+		for i := 0; i < field.Len(); i++ {
+			suffix := "[" + strconv.Itoa(i) + "]"
+			if err := b.setNestedDefaults(field.Index(i), fieldPath+suffix, keyPath+suffix, s); err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
-func setField(field reflect.Value, value string) error {
-	if !field.CanSet() {
-		return nil
+// setPlanned binds node to field, using the setter of the plan for scalars.
+func (b binding) setPlanned(field reflect.Value, fp *fieldPlan, node *formNode, fieldPath, keyPath string, s *bindState) error {
+	if fp.err != nil {
+		return fp.err
 	}
+	if fp.set != nil {
+		if len(node.values) == 0 {
+			return nil
+		}
+		value := node.values[0]
+		return s.check(fp.set(field, value, fp.tags), fieldPath, keyPath, value, field.Type())
+	}
+	return b.setValue(field, node, fieldPath, keyPath, fp.tags, s)
+}
 
-	if field.Type() == reflect.TypeOf(time.Time{}) {
-		t, err := time.Parse(time.RFC3339, value)
-		if err != nil {
+// defaultNode holds the value of a `default:` tag. Slices of scalars take a
+// comma-separated list.
+func defaultNode(def string, typ reflect.Type) *formNode {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if !isScalarType(typ) && typ.Kind() == reflect.Slice && isScalarType(typ.Elem()) {
+		return &formNode{values: strings.Split(def, ",")}
+	}
+	return &formNode{values: []string{def}}
+}
+
+// setValue binds a node to a field of any supported shape: scalars, nested
+// structs, slices (from repeated keys or from indexes) and maps. Values that
+// don't parse are recorded in s; the returned error is reserved for fields
+// of a type that can't be bound at all.
+func (b binding) setValue(field reflect.Value, node *formNode, fieldPath, keyPath string, tags fieldTags, s *bindState) error {
+	typ := field.Type()
+	if isScalarType(typ) {
+		if len(node.values) == 0 {
+			return nil
+		}
+		return b.setScalar(field, node.values[0], fieldPath, keyPath, tags, s)
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		ptr := reflect.New(typ.Elem())
+		if err := b.setValue(ptr.Elem(), node, fieldPath, keyPath, tags, s); err != nil {
 			return err
 		}
-		field.Set(reflect.ValueOf(t))
+		field.Set(ptr)
+	case reflect.Struct:
+		return b.mapValues(field, node, fieldPath, keyPath, s)
+	case reflect.Slice:
+		return b.setSlice(field, node, fieldPath, keyPath, tags, s)
+	case reflect.Map:
+		return b.setMap(field, node, fieldPath, keyPath, tags, s)
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedType, typ.Kind())
+	}
+	return nil
+}
+
+// setScalar parses one value into field, recording a parse failure in s.
+func (b binding) setScalar(field reflect.Value, value, fieldPath, keyPath string, tags fieldTags, s *bindState) error {
+	return s.check(setFieldWith(field, value, tags), fieldPath, keyPath, value, field.Type())
+}
+
+// setSlice binds items[0].qty style keys by index, or falls back to the
+// repeated values of a plain key.
+func (b binding) setSlice(field reflect.Value, node *formNode, fieldPath, keyPath string, tags fieldTags, s *bindState) error {
+	limit := s.config.MaxElements
+	if len(node.children) == 0 {
+		if len(node.values) > limit {
+			s.fail(fieldPath, keyPath, "", field.Type(), fmt.Errorf("too many values: %d, maximum %d", len(node.values), limit))
+			return nil
+		}
+		if !s.allocate(len(node.values), fieldPath, keyPath, field.Type()) {
+			return nil
+		}
+		slice := reflect.MakeSlice(field.Type(), len(node.values), len(node.values))
+		for i, v := range node.values {
+			index := "[" + strconv.Itoa(i) + "]"
+			if err := b.setScalar(slice.Index(i), v, fieldPath+index, keyPath+index, tags, s); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
 		return nil
 	}
 
-	kind := field.Kind()
-	switch kind {
-	case reflect.String:
-		field.SetString(value)
-	case reflect.Int:
-		intValue, err := strconv.ParseInt(value, 10, field.Type().Bits())
-		if err != nil {
-			return err
+	length := 0
+	indexes := make(map[string]int, len(node.children))
+	for key := range node.children {
+		index, err := strconv.Atoi(key)
+		switch {
+		case err != nil || index < 0:
+			s.fail(fieldPath, keyPath+"["+key+"]", key, field.Type(), fmt.Errorf("invalid index %q", key))
+			continue
+		case index >= limit:
+			s.fail(fieldPath, keyPath+"["+key+"]", key, field.Type(), fmt.Errorf("index %d out of range, maximum %d", index, limit-1))
+			continue
 		}
-		field.SetInt(intValue)
-	case reflect.Uint:
-		uintValue, err := strconv.ParseUint(value, 10, field.Type().Bits())
-		if err != nil {
+		indexes[key] = index
+		length = max(length, index+1)
+	}
+	if !s.allocate(length, fieldPath, keyPath, field.Type()) {
+		return nil
+	}
+
+	slice := reflect.MakeSlice(field.Type(), length, length)
+	for key, index := range indexes {
+		suffix := "[" + key + "]"
+		if err := b.setValue(slice.Index(index), node.children[key], fieldPath+suffix, keyPath+suffix, tags, s); err != nil {
 			return err
 		}
-		field.SetUint(uintValue)
-	case reflect.Float64:
-		floatValue, err := strconv.ParseFloat(value, field.Type().Bits())
-		if err != nil {
-			return err
+	}
+	field.Set(slice)
+	return nil
+}
+
+// setMap binds meta[key]=v style keys.
+func (b binding) setMap(field reflect.Value, node *formNode, fieldPath, keyPath string, tags fieldTags, s *bindState) error {
+	typ := field.Type()
+	if len(node.children) > s.config.MaxElements {
+		s.fail(fieldPath, keyPath, "", typ, fmt.Errorf("too many map entries: %d, maximum %d", len(node.children), s.config.MaxElements))
+		return nil
+	}
+	if !s.allocate(len(node.children), fieldPath, keyPath, typ) {
+		return nil
+	}
+
+	if field.IsNil() {
+		field.Set(reflect.MakeMapWithSize(typ, len(node.children)))
+	}
+	for key, child := range node.children {
+		suffix := "[" + key + "]"
+		k := reflect.New(typ.Key()).Elem()
+		if err := setField(k, key); err != nil {
+			if errors.Is(err, errUnsupportedType) {
+				return err
+			}
+			s.fail(fieldPath+suffix, keyPath+suffix, key, typ.Key(), err)
+			continue
 		}
-		field.SetFloat(floatValue)
-	case reflect.Bool:
-		boolValue, err := strconv.ParseBool(value)
-		if err != nil {
+		v := reflect.New(typ.Elem()).Elem()
+		if err := b.setValue(v, child, fieldPath+suffix, keyPath+suffix, tags, s); err != nil {
 			return err
 		}
-		field.SetBool(boolValue)
-	default:
-		return fmt.Errorf("unsupported type: %s", kind)
+		field.SetMapIndex(k, v)
 	}
 	return nil
 }
+
+// isScalarType reports whether t is bound from a single string, as opposed
+// to being built from nested keys.
+func isScalarType(t reflect.Type) bool {
+	if t == timeType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Map:
+		return false
+	case reflect.Ptr:
+		return isScalarType(t.Elem())
+	}
+	return true
+}
+
+// formNode is one level of a tree built from keys such as address.city,
+// items[0].qty or meta[key]. Leaves hold the submitted values.
+type formNode struct {
+	values   []string
+	children map[string]*formNode
+}
+
+// newFormTree builds the tree of values. Keys nested deeper than MaxDepth
+// are recorded in s and left out.
+func newFormTree(values map[string][]string, s *bindState) *formNode {
+	root := &formNode{}
+	for key, v := range values {
+		path := splitFormKey(key)
+		if len(path) > s.config.MaxDepth {
+			s.fail("", key, firstValue(v), nil, fmt.Errorf("nested deeper than %d levels", s.config.MaxDepth))
+			continue
+		}
+
+		node := root
+		for _, segment := range path {
+			node = node.child(segment)
+		}
+		node.values = append(node.values, v...)
+	}
+	return root
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (n *formNode) child(segment string) *formNode {
+	if n.children == nil {
+		n.children = make(map[string]*formNode)
+	}
+	child, ok := n.children[segment]
+	if !ok {
+		child = &formNode{}
+		n.children[segment] = child
+	}
+	return child
+}
+
+// lookup follows a key, which may itself be nested, down the tree.
+func (n *formNode) lookup(key string) *formNode {
+	for _, segment := range splitFormKey(key) {
+		if n = n.children[segment]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// splitFormKey splits a key written with dot or bracket notation into its
+// segments: "items[0].qty" and "items.0.qty" both give [items 0 qty]. A
+// trailing "[]" is dropped so tags[]=a binds like tags=a. Malformed keys are
+// kept whole.
+func splitFormKey(key string) []string {
+	var segments []string
+	rest := key
+	for rest != "" {
+		i := strings.IndexAny(rest, ".[")
+		if i < 0 {
+			segments = append(segments, rest)
+			break
+		}
+		if rest[i] == '.' {
+			segments = append(segments, rest[:i])
+			rest = rest[i+1:]
+			continue
+		}
+
+		if i > 0 {
+			segments = append(segments, rest[:i])
+		}
+		end := strings.IndexByte(rest[i:], ']')
+		if end < 0 {
+			return []string{key}
+		}
+		inner := rest[i+1 : i+end]
+		rest = rest[i+end+1:]
+		if inner == "" && rest == "" {
+			break
+		}
+		segments = append(segments, inner)
+		rest = strings.TrimPrefix(rest, ".")
+	}
+
+	if len(segments) == 0 {
+		return []string{key}
+	}
+	return segments
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// fieldTags holds the tags of a field that change how its values are
+// parsed.
+type fieldTags struct {
+	// timeFormat is the layout of the `time_format:` tag.
+	timeFormat string
+	// location is where times without a zone are placed, from the
+	// `time_location:` tag. It defaults to UTC.
+	location *time.Location
+}
+
+// timeLayouts are tried in order when a field has no `time_format:` tag.
+// Besides RFC 3339 they cover what the browser's date, datetime-local, month
+// and time inputs submit.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"15:04:05.999999999",
+	"15:04",
+}
+
+var locationCache sync.Map // name -> *time.Location
+
+func newFieldTags(sf reflect.StructField) (fieldTags, error) {
+	tags := fieldTags{timeFormat: sf.Tag.Get("time_format")}
+	name := sf.Tag.Get("time_location")
+	if name == "" {
+		return tags, nil
+	}
+	if loc, ok := locationCache.Load(name); ok {
+		tags.location = loc.(*time.Location)
+		return tags, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return tags, fmt.Errorf("time_location on %s: %w", sf.Name, err)
+	}
+	locationCache.Store(name, loc)
+	tags.location = loc
+	return tags, nil
+}
+
+// parseTime parses value with the layout of the tags, or else with the first
+// of timeLayouts that matches.
+func parseTime(value string, tags fieldTags) (time.Time, error) {
+	loc := tags.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if tags.timeFormat != "" {
+		return time.ParseInLocation(tags.timeFormat, value, loc)
+	}
+
+	var firstErr error
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err == nil {
+			return t, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, firstErr
+}
+
+// setField parses value into field. Pointers are allocated only when there
+// is a value, so absent keys leave them nil. time.Time, time.Duration and
+// encoding.TextUnmarshaler implementations are handled before falling back
+// to the kind, which covers named types such as `type Status int`.
+func setField(field reflect.Value, value string) error {
+	return setFieldWith(field, value, fieldTags{})
+}
+
+// setFieldWith is setField for a field with tags.
+func setFieldWith(field reflect.Value, value string, tags fieldTags) error {
+	if !field.CanSet() {
+		return nil
+	}
+	return setterFor(field.Type())(field, value, tags)
+}
+
+// bindReason turns a parse error into a message fit for the end user.
+func bindReason(typ reflect.Type, err error) string {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	var timeErr *time.ParseError
+	var numErr *strconv.NumError
+	switch {
+	case errors.As(err, &timeErr):
+		return "must be a valid date and time"
+	case typ == durationType:
+		return "must be a valid duration, such as 1h30m"
+	case !errors.As(err, &numErr):
+		return err.Error()
+	case errors.Is(numErr.Err, strconv.ErrRange):
+		return "is out of range"
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "must be true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "must be a whole number"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "must be a positive whole number"
+	case reflect.Float32, reflect.Float64:
+		return "must be a number"
+	}
+	return err.Error()
+}
@@ -2,9 +2,15 @@ package ron
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -71,6 +77,142 @@ func Test_BindJSON(t *testing.T) {
 	}
 }
 
+func Test_BindJSONErrors(t *testing.T) {
+	type payload struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+		Extra any    `json:"extra"`
+	}
+
+	tests := map[string]struct {
+		contentType  string
+		body         string
+		config       func(*JSONBindingConfig)
+		expectedCode int
+	}{
+		"charset parameter": {
+			contentType: "application/json; charset=utf-8",
+			body:        `{"name":"foo"}`,
+		},
+		"json suffix": {
+			contentType: "application/vnd.api+json",
+			body:        `{"name":"foo"}`,
+		},
+		"trailing whitespace": {
+			contentType: "application/json",
+			body:        "{\"name\":\"foo\"}\n\t ",
+		},
+		"wrong content type": {
+			contentType:  "text/plain",
+			body:         `{"name":"foo"}`,
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		"missing content type": {
+			body:         `{"name":"foo"}`,
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		"empty body": {
+			contentType:  "application/json",
+			expectedCode: http.StatusBadRequest,
+		},
+		"malformed": {
+			contentType:  "application/json",
+			body:         `{"name":`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"syntax error": {
+			contentType:  "application/json",
+			body:         `{"name" "foo"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"trailing value": {
+			contentType:  "application/json",
+			body:         `{"name":"foo"}{"name":"bar"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"trailing garbage": {
+			contentType:  "application/json",
+			body:         `{"name":"foo"} garbage`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"wrong type": {
+			contentType:  "application/json",
+			body:         `{"count":"many"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"unknown field allowed": {
+			contentType: "application/json",
+			body:        `{"name":"foo","age":3}`,
+		},
+		"unknown field strict": {
+			contentType: "application/json",
+			body:        `{"name":"foo","age":3}`,
+			config: func(jc *JSONBindingConfig) {
+				jc.DisallowUnknownFields = true
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		"too large": {
+			contentType: "application/json",
+			body:        `{"name":"` + strings.Repeat("a", 64) + `"}`,
+			config: func(jc *JSONBindingConfig) {
+				jc.MaxBodySize = 32
+			},
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			c := &CTX{R: req, W: newResponseWriter(httptest.NewRecorder()), E: New(func(e *Engine) {
+				if tt.config != nil {
+					tt.config(&e.Config.Binding.JSON)
+				}
+			})}
+
+			var p payload
+			err := c.BindJSON(&p)
+			if tt.expectedCode == 0 {
+				if err != nil {
+					t.Fatalf("BindJSON() failed: %v", err)
+				}
+				if p.Name != "foo" {
+					t.Errorf("Expected name: foo, Actual: %s", p.Name)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error, Actual: %+v", p)
+			}
+			if code := StatusCode(err); code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d (%v)", tt.expectedCode, code, err)
+			}
+		})
+	}
+}
+
+func Test_BindJSONUseNumber(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"extra":12345678901234567890}`))
+	req.Header.Set("Content-Type", "application/json")
+	c := &CTX{R: req, E: New(func(e *Engine) {
+		e.Config.Binding.JSON.UseNumber = true
+	})}
+
+	var p struct {
+		Extra any `json:"extra"`
+	}
+	if err := c.BindJSON(&p); err != nil {
+		t.Fatalf("BindJSON() failed: %v", err)
+	}
+	if n, ok := p.Extra.(json.Number); !ok || n.String() != "12345678901234567890" {
+		t.Errorf("Expected json.Number 12345678901234567890, Actual: %#v", p.Extra)
+	}
+}
+
 func Test_BindForm(t *testing.T) {
 	expected, actualTime := expectedStruct()
 	req := httptest.NewRequest("POST", "/", nil)
@@ -104,3 +246,550 @@ func Test_BindForm(t *testing.T) {
 		t.Errorf("Expected: %v, Actual: %v", expected, foo)
 	}
 }
+
+type BarParams struct {
+	ID       int      `path:"id"`
+	Page     int      `query:"page"`
+	Tags     []string `query:"tag"`
+	Token    string   `header:"x-api-token"`
+	Session  string   `cookie:"session"`
+	Name     string   `query:"name" json:"name" form:"name"`
+	Ignored  string   `query:"-"`
+	Untagged string
+}
+
+func Test_BindSources(t *testing.T) {
+	req := httptest.NewRequest("GET", "/items/7?page=2&tag=a&tag=b&name=query&Untagged=x&Ignored=y", nil)
+	req.SetPathValue("id", "7")
+	req.Header.Set("X-Api-Token", "secret")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	c := &CTX{R: req}
+
+	tests := []struct {
+		name     string
+		bind     func(any) error
+		expected BarParams
+	}{
+		{"BindQuery", c.BindQuery, BarParams{Page: 2, Tags: []string{"a", "b"}, Name: "query"}},
+		{"BindPath", c.BindPath, BarParams{ID: 7}},
+		{"BindHeader", c.BindHeader, BarParams{Token: "secret"}},
+		{"BindCookie", c.BindCookie, BarParams{Session: "abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actual BarParams
+			if err := tt.bind(&actual); err != nil {
+				t.Fatalf("%s() failed: %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("Expected: %+v, Actual: %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_Bind(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		body        string
+		expected    BarParams
+	}{
+		"json body": {
+			contentType: "application/json; charset=utf-8",
+			body:        `{"name":"json"}`,
+			expected:    BarParams{ID: 7, Page: 2, Token: "secret", Session: "abc", Name: "json"},
+		},
+		"form body": {
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=form",
+			expected:    BarParams{ID: 7, Page: 2, Token: "secret", Session: "abc", Name: "form"},
+		},
+		"no body": {
+			expected: BarParams{ID: 7, Page: 2, Token: "secret", Session: "abc", Name: "query"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := New()
+			e.POST("/items/{id}", func(c *CTX, ctx context.Context) {
+				var actual BarParams
+				if err := c.Bind(&actual); err != nil {
+					t.Fatalf("Bind() failed: %v", err)
+				}
+				if !reflect.DeepEqual(tt.expected, actual) {
+					t.Errorf("Expected: %+v, Actual: %+v", tt.expected, actual)
+				}
+			})
+
+			req := httptest.NewRequest("POST", "/items/7?page=2&name=query", strings.NewReader(tt.body))
+			if tt.body == "" {
+				req.Body = http.NoBody
+			}
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("X-Api-Token", "secret")
+			req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+			e.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+}
+
+func Test_BindInvalidTarget(t *testing.T) {
+	c := &CTX{R: httptest.NewRequest("GET", "/", nil)}
+	var notStruct int
+	if err := c.BindQuery(&notStruct); err == nil {
+		t.Error("Expected error binding into a non struct")
+	}
+}
+
+type Status int
+
+type Level struct {
+	Name string
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return errors.New("empty level")
+	}
+	l.Name = strings.ToUpper(string(text))
+	return nil
+}
+
+func Test_setField(t *testing.T) {
+	type fields struct {
+		Int8     int8
+		Int16    int16
+		Int32    int32
+		Int64    int64
+		Uint8    uint8
+		Uint16   uint16
+		Uint32   uint32
+		Uint64   uint64
+		Float32  float32
+		Status   Status
+		IntPtr   *int
+		StrPtr   *string
+		Duration time.Duration
+		Level    Level
+		Addr     netip.Addr
+		IP       net.IP
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		value    string
+		expected any
+		wantErr  bool
+	}{
+		{"int8", "Int8", "-128", int8(-128), false},
+		{"int8 overflow", "Int8", "128", nil, true},
+		{"int16", "Int16", "32767", int16(32767), false},
+		{"int32", "Int32", "-5", int32(-5), false},
+		{"int64", "Int64", "9223372036854775807", int64(9223372036854775807), false},
+		{"uint8", "Uint8", "255", uint8(255), false},
+		{"uint8 overflow", "Uint8", "256", nil, true},
+		{"uint8 negative", "Uint8", "-1", nil, true},
+		{"uint16", "Uint16", "65535", uint16(65535), false},
+		{"uint32", "Uint32", "7", uint32(7), false},
+		{"uint64", "Uint64", "18446744073709551615", uint64(18446744073709551615), false},
+		{"float32", "Float32", "1.5", float32(1.5), false},
+		{"float32 overflow", "Float32", "1e39", nil, true},
+		{"named type", "Status", "2", Status(2), false},
+		{"pointer", "IntPtr", "3", 3, false},
+		{"string pointer", "StrPtr", "foo", "foo", false},
+		{"duration", "Duration", "1m30s", 90 * time.Second, false},
+		{"invalid duration", "Duration", "soon", nil, true},
+		{"text unmarshaler", "Level", "debug", Level{Name: "DEBUG"}, false},
+		{"text unmarshaler error", "Level", "", nil, true},
+		{"stdlib text unmarshaler", "Addr", "192.0.2.1", netip.MustParseAddr("192.0.2.1"), false},
+		{"slice text unmarshaler", "IP", "192.0.2.1", net.ParseIP("192.0.2.1"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f fields
+			field := reflect.ValueOf(&f).Elem().FieldByName(tt.field)
+			err := setField(field, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error: %v, Actual: %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			actual := field.Interface()
+			if field.Kind() == reflect.Ptr {
+				actual = field.Elem().Interface()
+			}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("Expected: %v, Actual: %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_BindFormPointers(t *testing.T) {
+	type params struct {
+		Limit  *int           `form:"limit"`
+		Offset *int           `form:"offset"`
+		IDs    []*uint16      `form:"id"`
+		IP     net.IP         `form:"ip"`
+		Wait   *time.Duration `form:"wait"`
+	}
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Form = map[string][]string{
+		"limit": {"10"},
+		"id":    {"1", "2"},
+		"ip":    {"::1"},
+	}
+	c := &CTX{R: req}
+
+	var p params
+	if err := c.BindForm(&p); err != nil {
+		t.Fatalf("BindForm() failed: %v", err)
+	}
+	if p.Limit == nil || *p.Limit != 10 {
+		t.Errorf("Expected limit: 10, Actual: %v", p.Limit)
+	}
+	if p.Offset != nil || p.Wait != nil {
+		t.Errorf("Expected absent pointers to stay nil, Actual: %v %v", p.Offset, p.Wait)
+	}
+	if len(p.IDs) != 2 || *p.IDs[0] != 1 || *p.IDs[1] != 2 {
+		t.Errorf("Expected ids: [1 2], Actual: %v", p.IDs)
+	}
+	if !p.IP.Equal(net.IPv6loopback) {
+		t.Errorf("Expected ip: ::1, Actual: %v", p.IP)
+	}
+}
+
+func Test_splitFormKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected []string
+	}{
+		{"name", []string{"name"}},
+		{"address.city", []string{"address", "city"}},
+		{"items[0].qty", []string{"items", "0", "qty"}},
+		{"items[0][qty]", []string{"items", "0", "qty"}},
+		{"meta[a.b]", []string{"meta", "a.b"}},
+		{"tags[]", []string{"tags"}},
+		{"broken[", []string{"broken["}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if actual := splitFormKey(tt.key); !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("Expected: %q, Actual: %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+type Address struct {
+	Street string `form:"street"`
+	City   string `form:"city"`
+}
+
+type Item struct {
+	SKU string `form:"sku"`
+	Qty int    `form:"qty"`
+}
+
+type Order struct {
+	Address  Address           `form:"address"`
+	Billing  *Address          `form:"billing"`
+	Shipping *Address          `form:"shipping"`
+	Items    []Item            `form:"items"`
+	Meta     map[string]string `form:"meta"`
+	Counts   map[string]int    `form:"counts"`
+	Tags     []string          `form:"tags"`
+}
+
+func Test_BindFormNested(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Form = map[string][]string{
+		"address.street":  {"Main St"},
+		"address[city]":   {"Springfield"},
+		"billing.city":    {"Shelbyville"},
+		"items[0].sku":    {"A1"},
+		"items[0].qty":    {"2"},
+		"items[1][sku]":   {"B2"},
+		"items[1][qty]":   {"5"},
+		"meta[source]":    {"web"},
+		"meta[campaign]":  {"spring"},
+		"counts.visits":   {"3"},
+		"tags[]":          {"a", "b"},
+		"ignored[0].deep": {"x"},
+	}
+	c := &CTX{R: req}
+
+	expected := Order{
+		Address: Address{Street: "Main St", City: "Springfield"},
+		Billing: &Address{City: "Shelbyville"},
+		Items:   []Item{{SKU: "A1", Qty: 2}, {SKU: "B2", Qty: 5}},
+		Meta:    map[string]string{"source": "web", "campaign": "spring"},
+		Counts:  map[string]int{"visits": 3},
+		Tags:    []string{"a", "b"},
+	}
+
+	var actual Order
+	if err := c.BindForm(&actual); err != nil {
+		t.Fatalf("BindForm() failed: %v", err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected: %+v, Actual: %+v", expected, actual)
+	}
+}
+
+func Test_BindFormLimits(t *testing.T) {
+	tests := map[string]struct {
+		form map[string][]string
+	}{
+		"index out of range": {
+			form: map[string][]string{"items[5].qty": {"1"}},
+		},
+		"negative index": {
+			form: map[string][]string{"items[-1].qty": {"1"}},
+		},
+		"too many map entries": {
+			form: map[string][]string{"meta[a]": {"1"}, "meta[b]": {"2"}, "meta[c]": {"3"}, "meta[d]": {"4"}, "meta[e]": {"5"}, "meta[f]": {"6"}},
+		},
+		"too many values": {
+			form: map[string][]string{"tags": {"1", "2", "3", "4", "5", "6"}},
+		},
+		"too deep": {
+			form: map[string][]string{"a[b][c][d]": {"1"}},
+		},
+		"too many elements in total": {
+			form: map[string][]string{"items[4].qty": {"1"}, "tags": {"a"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", nil)
+			req.Form = tt.form
+			c := &CTX{R: req, E: New(func(e *Engine) {
+				e.Config.Binding.MaxDepth = 3
+				e.Config.Binding.MaxElements = 5
+			})}
+
+			var order Order
+			if err := c.BindForm(&order); err == nil {
+				t.Errorf("Expected error, Actual: %+v", order)
+			}
+		})
+	}
+}
+
+func Test_BindingConfigDefaults(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Form = map[string][]string{"items[1].qty": {"2"}, "meta[a]": {"b"}}
+	c := &CTX{R: req, E: New(func(e *Engine) {
+		e.Config.Binding = BindingConfig{Validate: true}
+	})}
+
+	var order Order
+	if err := c.BindForm(&order); err != nil {
+		t.Fatalf("Expected the default limits for zero fields, Actual: %v", err)
+	}
+	if len(order.Items) != 2 || order.Meta["a"] != "b" {
+		t.Errorf("Expected nested keys to be bound, Actual: %+v", order)
+	}
+}
+
+func Test_BindingErrors(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Form = map[string][]string{
+		"items.0.qty":  {"two"},
+		"items[1].qty": {"3"},
+		"counts[a]":    {"1.5"},
+		"address.city": {"Madrid"},
+	}
+	c := &CTX{R: req, E: New()}
+
+	var order Order
+	err := c.BindForm(&order)
+
+	var errs BindingErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected BindingErrors, Actual: %v", err)
+	}
+	if StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("Expected status code: %d, Actual: %d", http.StatusBadRequest, StatusCode(err))
+	}
+
+	expected := map[string]BindingError{
+		"items[0].qty": {Source: "form", Field: "Items[0].Qty", Key: "items[0].qty", Value: "two", Reason: "must be a whole number"},
+		"counts[a]":    {Source: "form", Field: "Counts[a]", Key: "counts[a]", Value: "1.5", Reason: "must be a whole number"},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, Actual: %v", len(expected), errs)
+	}
+	for _, actual := range errs {
+		want, ok := expected[actual.Key]
+		if !ok {
+			t.Errorf("Unexpected error: %v", actual)
+			continue
+		}
+		actual.Err = nil
+		if actual != want {
+			t.Errorf("Expected: %+v, Actual: %+v", want, actual)
+		}
+	}
+
+	if order.Address.City != "Madrid" || len(order.Items) != 2 || order.Items[1].Qty != 3 {
+		t.Errorf("Expected valid fields to be bound, Actual: %+v", order)
+	}
+}
+
+func Test_BindCollectsErrorsAcrossSources(t *testing.T) {
+	type params struct {
+		Page  int  `query:"page"`
+		Debug bool `header:"X-Debug"`
+	}
+
+	req := httptest.NewRequest("GET", "/?page=first", nil)
+	req.Header.Set("X-Debug", "maybe")
+	c := &CTX{R: req, E: New()}
+
+	var errs BindingErrors
+	if err := c.Bind(&params{}); !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Expected 2 BindingErrors, Actual: %v", err)
+	}
+	if errs[0].Source != "header" || errs[0].Reason != "must be true or false" {
+		t.Errorf("Unexpected header error: %+v", errs[0])
+	}
+	if errs[1].Source != "query" || errs[1].Key != "page" || errs[1].Value != "first" {
+		t.Errorf("Unexpected query error: %+v", errs[1])
+	}
+}
+
+func Test_BindFormTimes(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+
+	type event struct {
+		Date     time.Time   `form:"date"`
+		Local    time.Time   `form:"local"`
+		Month    time.Time   `form:"month"`
+		Starts   time.Time   `form:"starts"`
+		Custom   time.Time   `form:"custom" time_format:"02/01/2006"`
+		InMadrid time.Time   `form:"in_madrid" time_format:"2006-01-02 15:04" time_location:"Europe/Madrid"`
+		Zoned    *time.Time  `form:"zoned" time_location:"Europe/Madrid"`
+		Days     []time.Time `form:"days" time_format:"02/01/2006"`
+	}
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Form = map[string][]string{
+		"date":      {"2024-03-15"},
+		"local":     {"2024-03-15T09:30"},
+		"month":     {"2024-03"},
+		"starts":    {"18:45"},
+		"custom":    {"15/03/2024"},
+		"in_madrid": {"2024-03-15 09:30"},
+		"zoned":     {"2024-03-15T09:30:00+02:00"},
+		"days":      {"01/03/2024", "02/03/2024"},
+	}
+	c := &CTX{R: req}
+
+	var e event
+	if err := c.BindForm(&e); err != nil {
+		t.Fatalf("BindForm() failed: %v", err)
+	}
+
+	expected := map[string]struct {
+		actual, expected time.Time
+	}{
+		"date":      {e.Date, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		"local":     {e.Local, time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)},
+		"month":     {e.Month, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		"starts":    {e.Starts, time.Date(0, 1, 1, 18, 45, 0, 0, time.UTC)},
+		"custom":    {e.Custom, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		"in_madrid": {e.InMadrid, time.Date(2024, 3, 15, 9, 30, 0, 0, madrid)},
+		"zoned":     {*e.Zoned, time.Date(2024, 3, 15, 7, 30, 0, 0, time.UTC)},
+		"days[1]":   {e.Days[1], time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for key, tt := range expected {
+		if !tt.actual.Equal(tt.expected) {
+			t.Errorf("Expected %s: %v, Actual: %v", key, tt.expected, tt.actual)
+		}
+	}
+	if e.InMadrid.Location().String() != madrid.String() {
+		t.Errorf("Expected location: %v, Actual: %v", madrid, e.InMadrid.Location())
+	}
+
+	req.Form = map[string][]string{"custom": {"2024-03-15"}}
+	var invalid event
+	var errs BindingErrors
+	if err := c.BindForm(&invalid); !errors.As(err, &errs) || errs[0].Key != "custom" {
+		t.Errorf("Expected BindingErrors for custom, Actual: %v", err)
+	}
+}
+
+func Test_BindDefaults(t *testing.T) {
+	type search struct {
+		Query string        `query:"q"`
+		Page  int           `query:"page" form:"page" default:"1"`
+		Limit *int          `query:"limit" default:"20"`
+		Sort  []string      `query:"sort" default:"name,-created"`
+		Since time.Time     `query:"since" default:"2024-01-01"`
+		Wait  time.Duration `header:"X-Wait" default:"5s"`
+	}
+
+	tests := map[string]struct {
+		url      string
+		expected search
+	}{
+		"missing keys": {
+			url: "/?q=foo",
+			expected: search{
+				Query: "foo",
+				Page:  1,
+				Limit: func() *int { n := 20; return &n }(),
+				Sort:  []string{"name", "-created"},
+				Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Wait:  5 * time.Second,
+			},
+		},
+		"present keys": {
+			url: "/?page=3&limit=50&sort=price&since=2024-06-01",
+			expected: search{
+				Page:  3,
+				Limit: func() *int { n := 50; return &n }(),
+				Sort:  []string{"price"},
+				Since: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+				Wait:  5 * time.Second,
+			},
+		},
+		"explicit zero values": {
+			url: "/?page=0&limit=0&since=0001-01-01",
+			expected: search{
+				Limit: func() *int { n := 0; return &n }(),
+				Sort:  []string{"name", "-created"},
+				Wait:  5 * time.Second,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.url, strings.NewReader(""))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			c := &CTX{R: req, E: New()}
+
+			var actual search
+			if err := c.Bind(&actual); err != nil {
+				t.Fatalf("Bind() failed: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Expected: %+v, Actual: %+v", tt.expected, actual)
+			}
+		})
+	}
+}
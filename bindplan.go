@@ -0,0 +1,224 @@
+package ron
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// structPlan is what a binding needs to know about a struct type. It is
+	// worked out once per binding and type, so binding a request doesn't
+	// walk the fields or parse tags again.
+	structPlan struct {
+		fields []fieldPlan
+	}
+
+	fieldPlan struct {
+		// index is the path to the field through embedded structs, for
+		// reflect.Value.FieldByIndex.
+		index []int
+		// name is the Go name of the field.
+		name string
+		// key is the tag value after the binding's normalization.
+		key  string
+		tags fieldTags
+		// def holds the `default:` tag, or nil without one.
+		def  *formNode
+		file bool
+		// set parses fields bound from a single value. It is nil for
+		// structs, slices and maps.
+		set setter
+		// err reports a tag that can't be used, such as an unknown
+		// time_location. It is returned when the field is bound.
+		err error
+	}
+
+	planKey struct {
+		tag string
+		typ reflect.Type
+	}
+
+	// setter parses value into field, a value of the type it was built for.
+	setter func(field reflect.Value, value string, tags fieldTags) error
+)
+
+var (
+	planCache   sync.Map // planKey -> *structPlan
+	setterCache sync.Map // reflect.Type -> setter
+)
+
+// plan returns the cached plan of t for b, building it on first use.
+func (b binding) plan(t reflect.Type) *structPlan {
+	key := planKey{tag: b.tag, typ: t}
+	if p, ok := planCache.Load(key); ok {
+		return p.(*structPlan)
+	}
+	p, _ := planCache.LoadOrStore(key, &structPlan{fields: b.planFields(t, nil)})
+	return p.(*structPlan)
+}
+
+// planFields lists the fields of t bound by b. Fields of embedded structs
+// are promoted, as if declared in t.
+func (b binding) planFields(t reflect.Type, index []int) []fieldPlan {
+	var fields []fieldPlan
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, b.planFields(sf.Type, fieldIndex)...)
+			continue
+		}
+
+		key := sf.Tag.Get(b.tag)
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			if !b.nameFallback {
+				continue
+			}
+			key = sf.Name
+		}
+		if b.key != nil {
+			key = b.key(key)
+		}
+
+		fp := fieldPlan{
+			index: fieldIndex,
+			name:  sf.Name,
+			key:   key,
+			file:  isFileType(sf.Type),
+		}
+		fp.tags, fp.err = newFieldTags(sf)
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			fp.def = defaultNode(def, sf.Type)
+		}
+		if !fp.file && isScalarType(sf.Type) {
+			fp.set = setterFor(sf.Type)
+		}
+		fields = append(fields, fp)
+	}
+	return fields
+}
+
+// setterFor returns the cached setter of t.
+func setterFor(t reflect.Type) setter {
+	if set, ok := setterCache.Load(t); ok {
+		return set.(setter)
+	}
+	set, _ := setterCache.LoadOrStore(t, newSetter(t))
+	return set.(setter)
+}
+
+// newSetter resolves once what setField decides on every call: time.Time,
+// time.Duration and encoding.TextUnmarshaler come first, then the kind.
+func newSetter(t reflect.Type) setter {
+	if t.Kind() == reflect.Ptr {
+		elem := setterFor(t.Elem())
+		return func(field reflect.Value, value string, tags fieldTags) error {
+			ptr := reflect.New(t.Elem())
+			if err := elem(ptr.Elem(), value, tags); err != nil {
+				return err
+			}
+			field.Set(ptr)
+			return nil
+		}
+	}
+
+	switch {
+	case t == timeType:
+		return setTime
+	case t == durationType:
+		return setDuration
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return setText
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return setString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return setInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return setUint
+	case reflect.Float32, reflect.Float64:
+		return setFloat
+	case reflect.Bool:
+		return setBool
+	}
+	err := fmt.Errorf("%w: %s", errUnsupportedType, t.Kind())
+	return func(reflect.Value, string, fieldTags) error {
+		return err
+	}
+}
+
+func setTime(field reflect.Value, value string, tags fieldTags) error {
+	t, err := parseTime(value, tags)
+	if err != nil {
+		return err
+	}
+	field.Set(reflect.ValueOf(t))
+	return nil
+}
+
+func setDuration(field reflect.Value, value string, _ fieldTags) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	field.SetInt(int64(d))
+	return nil
+}
+
+func setText(field reflect.Value, value string, _ fieldTags) error {
+	return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+}
+
+func setString(field reflect.Value, value string, _ fieldTags) error {
+	field.SetString(value)
+	return nil
+}
+
+func setInt(field reflect.Value, value string, _ fieldTags) error {
+	n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+	if err != nil {
+		return err
+	}
+	field.SetInt(n)
+	return nil
+}
+
+func setUint(field reflect.Value, value string, _ fieldTags) error {
+	n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+	if err != nil {
+		return err
+	}
+	field.SetUint(n)
+	return nil
+}
+
+func setFloat(field reflect.Value, value string, _ fieldTags) error {
+	f, err := strconv.ParseFloat(value, field.Type().Bits())
+	if err != nil {
+		return err
+	}
+	field.SetFloat(f)
+	return nil
+}
+
+func setBool(field reflect.Value, value string, _ fieldTags) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	field.SetBool(b)
+	return nil
+}
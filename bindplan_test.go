@@ -0,0 +1,318 @@
+package ron
+
+import (
+	"encoding"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type benchSignup struct {
+	Name      string    `form:"name"`
+	Email     string    `form:"email"`
+	Age       int       `form:"age"`
+	Height    float64   `form:"height"`
+	Active    bool      `form:"active"`
+	Plan      *string   `form:"plan"`
+	Birthday  time.Time `form:"birthday"`
+	Interests []string  `form:"interests"`
+	Address   Address   `form:"address"`
+	Ignored   string    `form:"-"`
+}
+
+var benchSignupForm = map[string][]string{
+	"name":           {"Ada"},
+	"email":          {"ada@example.com"},
+	"age":            {"36"},
+	"height":         {"1.65"},
+	"active":         {"true"},
+	"plan":           {"pro"},
+	"birthday":       {"1815-12-10"},
+	"interests":      {"math", "engines"},
+	"address.street": {"St James's Square"},
+	"address.city":   {"London"},
+}
+
+func Test_bindingPlan(t *testing.T) {
+	type Embedded struct {
+		Page int `query:"page"`
+	}
+	type params struct {
+		Embedded
+		Query    string `query:"q"`
+		Untagged string
+		Skipped  string `query:"-"`
+		hidden   string `query:"hidden"`
+	}
+
+	typ := reflect.TypeOf(params{})
+	p := queryBinding.plan(typ)
+	if p != queryBinding.plan(typ) {
+		t.Error("Expected the plan to be cached")
+	}
+	if p == formBinding.plan(typ) {
+		t.Error("Expected a plan per binding")
+	}
+
+	expected := []struct {
+		key   string
+		index []int
+	}{
+		{"page", []int{0, 0}},
+		{"q", []int{1}},
+	}
+	if len(p.fields) != len(expected) {
+		t.Fatalf("Expected %d fields, Actual: %+v", len(expected), p.fields)
+	}
+	for i, e := range expected {
+		if p.fields[i].key != e.key || !reflect.DeepEqual(p.fields[i].index, e.index) {
+			t.Errorf("Expected field %s at %v, Actual: %s at %v", e.key, e.index, p.fields[i].key, p.fields[i].index)
+		}
+		if p.fields[i].set == nil {
+			t.Errorf("Expected a setter for %s", e.key)
+		}
+	}
+}
+
+func Test_referenceBindForm(t *testing.T) {
+	config := defaultBindingConfig()
+	var expected, actual benchSignup
+	if err := formBinding.bind(&expected, benchSignupForm, config, nil); err != nil {
+		t.Fatalf("bind() failed: %v", err)
+	}
+	if err := referenceBindForm(&actual, benchSignupForm, config); err != nil {
+		t.Fatalf("referenceBindForm() failed: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected: %+v, Actual: %+v", expected, actual)
+	}
+}
+
+// BenchmarkBindForm compares the cached plans with referenceBindForm, the
+// binder they replaced.
+func BenchmarkBindForm(b *testing.B) {
+	config := defaultBindingConfig()
+	binders := map[string]func(v any) error{
+		"plan": func(v any) error {
+			return formBinding.bind(v, benchSignupForm, config, nil)
+		},
+		"reference": func(v any) error {
+			return referenceBindForm(v, benchSignupForm, config)
+		},
+	}
+
+	for name, bind := range binders {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var s benchSignup
+				if err := bind(&s); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// referenceBindForm is the form binder as it was before the plans were
+// cached: the struct tags are read and the setter picked by reflection on
+// every call. It does the same work as bind for the scalars, pointers,
+// nested structs and slices of benchSignup.
+func referenceBindForm(v any, values map[string][]string, config BindingConfig) error {
+	s := &bindState{source: formBinding.tag, config: config, elements: config.MaxElements, bound: make(map[string]bool)}
+	val := reflect.ValueOf(v).Elem()
+	if err := referenceMapValues(val, newFormTree(values, s), "", "", s); err != nil {
+		return err
+	}
+	if err := referenceSetDefaults(val, "", "", s); err != nil {
+		return err
+	}
+	if len(s.errs) > 0 {
+		return s.errs
+	}
+	return nil
+}
+
+func referenceMapValues(val reflect.Value, node *formNode, fieldPath, keyPath string, s *bindState) error {
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		structField := typ.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		if field.Kind() == reflect.Struct && structField.Anonymous {
+			if err := referenceMapValues(field, node, fieldPath, keyPath, s); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag := structField.Tag.Get(formBinding.tag)
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = structField.Name
+		}
+		child := node.lookup(tag)
+		if child == nil {
+			continue
+		}
+		tags, err := newFieldTags(structField)
+		if err != nil {
+			return err
+		}
+
+		fieldPath, keyPath := joinPath(fieldPath, structField.Name), joinPath(keyPath, tag)
+		s.bound[fieldPath] = true
+		if err := referenceSetValue(field, child, fieldPath, keyPath, tags, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func referenceSetDefaults(val reflect.Value, fieldPath, keyPath string, s *bindState) error {
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		structField := typ.Field(i)
+		tag := structField.Tag.Get(formBinding.tag)
+		if !field.CanSet() || tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = structField.Name
+		}
+
+		fieldPath, keyPath := joinPath(fieldPath, structField.Name), joinPath(keyPath, tag)
+		def, ok := structField.Tag.Lookup("default")
+		if !ok {
+			if field.Kind() == reflect.Struct && field.Type() != timeType {
+				if err := referenceSetDefaults(field, fieldPath, keyPath, s); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if s.bound[fieldPath] || !field.IsZero() {
+			continue
+		}
+		tags, err := newFieldTags(structField)
+		if err != nil {
+			return err
+		}
+		if err := referenceSetValue(field, defaultNode(def, field.Type()), fieldPath, keyPath, tags, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func referenceSetValue(field reflect.Value, node *formNode, fieldPath, keyPath string, tags fieldTags, s *bindState) error {
+	switch {
+	case field.Kind() == reflect.Struct && field.Type() != timeType:
+		return referenceMapValues(field, node, fieldPath, keyPath, s)
+	case field.Kind() == reflect.Slice:
+		if !s.allocate(len(node.values), fieldPath, keyPath, field.Type()) {
+			return nil
+		}
+		slice := reflect.MakeSlice(field.Type(), len(node.values), len(node.values))
+		for i, value := range node.values {
+			index := "[" + strconv.Itoa(i) + "]"
+			if err := s.check(referenceSetField(slice.Index(i), value, tags), fieldPath+index, keyPath+index, value, field.Type().Elem()); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	case len(node.values) > 0:
+		return s.check(referenceSetField(field, node.values[0], tags), fieldPath, keyPath, node.values[0], field.Type())
+	}
+	return nil
+}
+
+func referenceSetField(field reflect.Value, value string, tags fieldTags) error {
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := referenceSetField(ptr.Elem(), value, tags); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	switch {
+	case field.Type() == timeType:
+		t, err := parseTime(value, tags)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	case field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType):
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch kind := field.Kind(); kind {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intValue, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(intValue)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		uintValue, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(uintValue)
+	case reflect.Float32, reflect.Float64:
+		floatValue, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(floatValue)
+	case reflect.Bool:
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(boolValue)
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedType, kind)
+	}
+	return nil
+}
+
+func BenchmarkBindQuery(b *testing.B) {
+	type search struct {
+		Query string   `query:"q"`
+		Page  int      `query:"page"`
+		Limit int      `query:"limit" default:"20"`
+		Sort  []string `query:"sort"`
+	}
+
+	req := httptest.NewRequest("GET", "/?q=foo&page=2&sort=name&sort=-created", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := &CTX{R: req}
+		var s search
+		if err := c.BindQuery(&s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package ron
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressConfig configures CompressMiddleware.
+type CompressConfig struct {
+	// Level is the compression level, from gzip.BestSpeed to
+	// gzip.BestCompression. It defaults to gzip.DefaultCompression.
+	Level int
+	// MinSize is the smallest body, in bytes, worth compressing. It
+	// defaults to 1024.
+	MinSize int
+	// ExcludedTypes are media types never compressed, in addition to
+	// images, audio, video and archives, which are compressed already. A
+	// type may end in /* to exclude a whole family.
+	ExcludedTypes []string
+}
+
+// compressedTypes are media types whose content is already compressed.
+var compressedTypes = []string{
+	"image/*",
+	"audio/*",
+	"video/*",
+	"font/woff",
+	"font/woff2",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/x-7z-compressed",
+	"application/x-bzip2",
+	"application/x-rar-compressed",
+	"application/zstd",
+	"application/pdf",
+	"application/octet-stream",
+	HeaderEventStream,
+}
+
+type (
+	// compressor is the part of gzip.Writer and zlib.Writer the
+	// middleware uses.
+	compressor interface {
+		io.WriteCloser
+		Flush() error
+		Reset(w io.Writer)
+	}
+
+	// compressWriter buffers the start of the body until it knows whether
+	// compressing is worth it, then either compresses the rest or passes
+	// it through.
+	compressWriter struct {
+		http.ResponseWriter
+		encoding string
+		minSize  int
+		excluded []string
+		pool     *sync.Pool
+
+		status  int
+		buf     []byte
+		decided bool
+		cw      compressor
+	}
+)
+
+// CompressMiddleware compresses responses with gzip or deflate, whichever
+// the Accept-Encoding header prefers, favouring gzip on a tie. deflate is
+// sent in the zlib format, as RFC 9110 defines it. Bodies smaller than
+// MinSize, bodies that already have a Content-Encoding and types in
+// ExcludedTypes are sent as they are. Server-Sent Events and WebSocket
+// requests are never compressed. Flush compresses and sends what has been
+// written so far, so handlers can stream.
+//
+//	e.USE(e.CompressMiddleware(ron.CompressConfig{}))
+//
+// It panics if Level is out of the range gzip accepts.
+func (e *Engine) CompressMiddleware(config CompressConfig) Middleware {
+	level := config.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		panic("ron: CompressMiddleware level " + strconv.Itoa(level) + " is out of range")
+	}
+	minSize := config.MinSize
+	if minSize == 0 {
+		minSize = 1024
+	}
+	excluded := append(append([]string(nil), compressedTypes...), config.ExcludedTypes...)
+
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, level)
+			return w
+		}},
+		"deflate": {New: func() any {
+			w, _ := zlib.NewWriterLevel(io.Discard, level)
+			return w
+		}},
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !headerHasToken(w.Header(), "Vary", "Accept-Encoding") {
+				w.Header().Add("Vary", "Accept-Encoding")
+			}
+			encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || e.isStreamingRoute(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				minSize:        minSize,
+				excluded:       excluded,
+				pool:           pools[encoding],
+			}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// acceptedEncoding returns gzip or deflate, whichever accept gives the
+// higher quality, or "" if it allows neither.
+func acceptedEncoding(accept string) string {
+	quality := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = v
+		}
+		quality[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{"gzip", "deflate"} {
+		q, ok := quality[coding]
+		if !ok {
+			q, ok = quality["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	// Informational responses may be followed by the final one.
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	if !bodyAllowed(code) || code == http.StatusPartialContent || !w.compressible() {
+		w.passThrough()
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.cw != nil {
+			return w.cw.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minSize {
+		if err := w.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush sends what has been written so far, compressing it if the response
+// can be compressed, whatever its size.
+func (w *compressWriter) Flush() {
+	w.FlushError()
+}
+
+// FlushError is the Flush used by http.ResponseController.
+func (w *compressWriter) FlushError() error {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		var err error
+		if w.compressible() {
+			err = w.startCompression()
+		} else {
+			err = w.passThrough()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if w.cw != nil {
+		if err := w.cw.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close sends a body still too small to compress as it is, or finishes the
+// compressed stream.
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			return nil
+		}
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if len(w.buf) > 0 && w.Header().Get("Content-Length") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(w.buf)))
+		}
+		return w.passThrough()
+	}
+	if w.cw == nil {
+		return nil
+	}
+	err := w.cw.Close()
+	w.cw.Reset(io.Discard)
+	w.pool.Put(w.cw)
+	w.cw = nil
+	return err
+}
+
+// compressible reports whether the headers set so far allow compressing.
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < w.minSize {
+		return false
+	}
+	if contentType := h.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		for _, excluded := range w.excluded {
+			if family, ok := strings.CutSuffix(excluded, "/*"); ok {
+				if strings.HasPrefix(mediaType, family+"/") {
+					return false
+				}
+			} else if mediaType == excluded {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (w *compressWriter) startCompression() error {
+	if !w.compressible() {
+		return w.passThrough()
+	}
+	w.decided = true
+
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		// Sniff the plain body; net/http would sniff the compressed one.
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	h.Del("Content-Length")
+	h.Del("Accept-Ranges")
+	h.Set("Content-Encoding", w.encoding)
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.cw = w.pool.Get().(compressor)
+	w.cw.Reset(w.ResponseWriter)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.cw.Write(buf)
+	return err
+}
+
+func (w *compressWriter) passThrough() error {
+	w.decided = true
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// bodyAllowed reports whether a response with status code may have a body.
+func bodyAllowed(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified && (code < 100 || code >= 200)
+}
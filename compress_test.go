@@ -0,0 +1,236 @@
+package ron
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_acceptedEncoding(t *testing.T) {
+	tests := map[string]struct {
+		given    string
+		expected string
+	}{
+		"empty":              {"", ""},
+		"gzip":               {"gzip", "gzip"},
+		"deflate":            {"deflate", "deflate"},
+		"tie prefers gzip":   {"deflate, gzip", "gzip"},
+		"quality":            {"gzip;q=0.5, deflate;q=0.8", "deflate"},
+		"refused":            {"gzip;q=0, deflate;q=0", ""},
+		"unsupported only":   {"br, zstd", ""},
+		"wildcard":           {"*", "gzip"},
+		"wildcard with gzip": {"gzip;q=0, *;q=0.5", "deflate"},
+		"case and spaces":    {" GZIP ; q=1 ", "gzip"},
+		"identity":           {"identity", ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := acceptedEncoding(tt.given); actual != tt.expected {
+				t.Errorf("Expected: %q, Actual: %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_CompressMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"name":"Ada Lovelace","email":"ada@example.com"}`, 100)
+
+	tests := map[string]struct {
+		givenAcceptEncoding string
+		givenStreaming      bool
+		givenHandler        func(c *CTX, ctx context.Context)
+		expectedCode        int
+		expectedEncoding    string
+		expectedLength      string
+		expectedBody        string
+	}{
+		"gzip": {
+			givenAcceptEncoding: "gzip, deflate",
+			givenHandler: func(c *CTX, ctx context.Context) {
+				c.W.Header().Set("Content-Length", "5000")
+				c.W.Header().Set("Content-Type", HeaderJSON)
+				c.W.Write([]byte(large))
+			},
+			expectedCode:     http.StatusOK,
+			expectedEncoding: "gzip",
+			expectedBody:     large,
+		},
+		"deflate": {
+			givenAcceptEncoding: "gzip;q=0.5, deflate",
+			givenHandler: func(c *CTX, ctx context.Context) {
+				c.JSON(http.StatusCreated, large)
+			},
+			expectedCode:     http.StatusCreated,
+			expectedEncoding: "deflate",
+			expectedBody:     `"` + strings.ReplaceAll(large, `"`, `\"`) + `"` + "\n",
+		},
+		"many small writes": {
+			givenAcceptEncoding: "gzip",
+			givenHandler: func(c *CTX, ctx context.Context) {
+				for i := 0; i < 100; i++ {
+					c.W.Write([]byte(large[:50]))
+				}
+			},
+			expectedCode:     http.StatusOK,
+			expectedEncoding: "gzip",
+			expectedBody:     strings.Repeat(large[:50], 100),
+		},
+		"small body": {
+			givenAcceptEncoding: "gzip",
+			givenHandler: func(c *CTX, ctx context.Context) {
+				c.W.Write([]byte("tiny"))
+			},
+			expectedCode:   http.StatusOK,
+			expectedLength: "4",
+			expectedBody:   "tiny",
+		},
+		"no accept encoding": {
+			givenHandler: func(c *CTX, ctx context.Context) {
+				c.W.Write([]byte(large))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: large,
+		},
+		"compressed type": {
+			givenAcceptEncoding: "gzip",
+			givenHandler: func(c *CTX, ctx context.Context) {
+				c.W.Header().Set("Content-Type", "image/png")
+				c.W.Write([]byte(large))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: large,
+		},
+		"already encoded": {
+			givenAcceptEncoding: "gzip",
+			givenHandler: func(c *CTX, ctx context.Context) {
+				c.W.Header().Set("Content-Encoding", "br")
+				c.W.Write([]byte(large))
+			},
+			expectedCode:     http.StatusOK,
+			expectedEncoding: "br",
+			expectedBody:     large,
+		},
+		"no content": {
+			givenAcceptEncoding: "gzip",
+			givenHandler: func(c *CTX, ctx context.Context) {
+				c.W.WriteHeader(http.StatusNoContent)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		"streaming route": {
+			givenAcceptEncoding: "gzip",
+			givenStreaming:      true,
+			givenHandler: func(c *CTX, ctx context.Context) {
+				c.W.Write([]byte(large))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: large,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := New()
+			e.USE(e.CompressMiddleware(CompressConfig{}))
+			e.GET("/", tt.givenHandler, func(r *Route) {
+				r.Streaming = tt.givenStreaming
+			})
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.givenAcceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.givenAcceptEncoding)
+			}
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, rr.Code)
+			}
+			if encoding := rr.Header().Get("Content-Encoding"); encoding != tt.expectedEncoding {
+				t.Errorf("Expected Content-Encoding: %q, Actual: %q", tt.expectedEncoding, encoding)
+			}
+			if vary := rr.Header().Get("Vary"); vary != "Accept-Encoding" {
+				t.Errorf("Expected Vary: Accept-Encoding, Actual: %q", vary)
+			}
+			if length := rr.Header().Get("Content-Length"); length != tt.expectedLength {
+				t.Errorf("Expected Content-Length: %q, Actual: %q", tt.expectedLength, length)
+			}
+
+			var body io.Reader = rr.Body
+			switch tt.expectedEncoding {
+			case "gzip":
+				zr, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader() failed: %v", err)
+				}
+				body = zr
+			case "deflate":
+				zr, err := zlib.NewReader(rr.Body)
+				if err != nil {
+					t.Fatalf("zlib.NewReader() failed: %v", err)
+				}
+				body = zr
+			}
+			actual, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("ReadAll() failed: %v", err)
+			}
+			if string(actual) != tt.expectedBody {
+				t.Errorf("Expected body: %.60s..., Actual: %.60s...", tt.expectedBody, actual)
+			}
+		})
+	}
+}
+
+func Test_CompressMiddlewareFlush(t *testing.T) {
+	e := New()
+	e.USE(e.CompressMiddleware(CompressConfig{}))
+	flushed := make(chan []byte, 1)
+	rr := httptest.NewRecorder()
+	e.GET("/", func(c *CTX, ctx context.Context) {
+		c.W.Write([]byte("hello"))
+		c.W.FlushError()
+		flushed <- append([]byte(nil), rr.Body.Bytes()...)
+		c.W.Write([]byte(" world"))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	e.ServeHTTP(rr, req)
+
+	if !rr.Flushed {
+		t.Error("Expected the response to be flushed")
+	}
+	if encoding := rr.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("Expected Content-Encoding: gzip, Actual: %q", encoding)
+	}
+
+	zr, err := gzip.NewReader(strings.NewReader(string(<-flushed)))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() failed: %v", err)
+	}
+	partial := make([]byte, 5)
+	if _, err := io.ReadFull(zr, partial); err != nil || string(partial) != "hello" {
+		t.Errorf("Expected the flushed data to decompress to hello, Actual: %q, %v", partial, err)
+	}
+
+	zr, _ = gzip.NewReader(rr.Body)
+	if actual, _ := io.ReadAll(zr); string(actual) != "hello world" {
+		t.Errorf("Expected: hello world, Actual: %q", actual)
+	}
+}
+
+func Test_CompressMiddlewareInvalidLevel(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	New().CompressMiddleware(CompressConfig{Level: 10})
+}
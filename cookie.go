@@ -0,0 +1,203 @@
+package ron
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type CookieOptions func(*http.Cookie)
+
+// maxCookieSize is the smallest limit browsers are required to support for
+// a cookie's name, value and attributes.
+const maxCookieSize = 4096
+
+var (
+	ErrNoCookieKeys   = errors.New("ron: no cookie keys configured")
+	ErrCookieTooLarge = errors.New("ron: cookie exceeds 4096 bytes")
+	// ErrInvalidCookie is returned when a signed or encrypted cookie was
+	// tampered with or written with a key that is no longer configured.
+	ErrInvalidCookie = &HTTPError{Code: http.StatusBadRequest, Err: errors.New("ron: invalid cookie")}
+)
+
+// SetCookie sets a cookie with secure defaults: Path=/, HttpOnly,
+// SameSite=Lax and Secure when the client connected over HTTPS, as resolved
+// by Scheme. Options run after the defaults and may override them.
+func (c *CTX) SetCookie(name, value string, opts ...CookieOptions) error {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   c.Scheme() == "https",
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cookie)
+		}
+	}
+
+	if err := cookie.Valid(); err != nil {
+		return err
+	}
+	if len(cookie.String()) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+
+	http.SetCookie(c.W, cookie)
+	return nil
+}
+
+// Cookie returns the value of the named cookie or http.ErrNoCookie.
+func (c *CTX) Cookie(name string) (string, error) {
+	cookie, err := c.R.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+// DeleteCookie tells the client to drop the named cookie. Pass the same Path
+// and Domain options used to set it.
+func (c *CTX) DeleteCookie(name string, opts ...CookieOptions) error {
+	return c.SetCookie(name, "", append(opts, func(cookie *http.Cookie) {
+		cookie.MaxAge = -1
+	})...)
+}
+
+// SetSignedCookie sets a cookie whose value is readable by the client but
+// protected against tampering with HMAC-SHA256.
+func (c *CTX) SetSignedCookie(name, value string, opts ...CookieOptions) error {
+	keys, err := c.cookieKeys()
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(value))
+	mac := cookieMAC(keys[0], name, encoded)
+	return c.SetCookie(name, encoded+"."+base64.RawURLEncoding.EncodeToString(mac), opts...)
+}
+
+// SignedCookie returns the value of a cookie set with SetSignedCookie.
+func (c *CTX) SignedCookie(name string) (string, error) {
+	keys, err := c.cookieKeys()
+	if err != nil {
+		return "", err
+	}
+	raw, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	encoded, sig, ok := strings.Cut(raw, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range keys {
+		if hmac.Equal(mac, cookieMAC(key, name, encoded)) {
+			value, err := base64.RawURLEncoding.DecodeString(encoded)
+			if err != nil {
+				return "", ErrInvalidCookie
+			}
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// SetEncryptedCookie sets a cookie whose value is encrypted and
+// authenticated with AES-256-GCM, so the client can neither read nor change
+// it.
+func (c *CTX) SetEncryptedCookie(name, value string, opts ...CookieOptions) error {
+	keys, err := c.cookieKeys()
+	if err != nil {
+		return err
+	}
+
+	aead, err := cookieAEAD(keys[0])
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return c.SetCookie(name, base64.RawURLEncoding.EncodeToString(sealed), opts...)
+}
+
+// EncryptedCookie returns the value of a cookie set with SetEncryptedCookie.
+func (c *CTX) EncryptedCookie(name string) (string, error) {
+	keys, err := c.cookieKeys()
+	if err != nil {
+		return "", err
+	}
+	raw, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range keys {
+		aead, err := cookieAEAD(key)
+		if err != nil {
+			return "", err
+		}
+		if len(sealed) < aead.NonceSize() {
+			return "", ErrInvalidCookie
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if value, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+func (c *CTX) cookieKeys() ([][]byte, error) {
+	if c.E == nil || c.E.Config == nil || len(c.E.Config.CookieKeys) == 0 {
+		return nil, ErrNoCookieKeys
+	}
+	return c.E.Config.CookieKeys, nil
+}
+
+// deriveCookieKey gives signing and encryption their own key, so a single
+// configured secret of any length can serve both.
+func deriveCookieKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("ron cookie " + purpose))
+	return mac.Sum(nil)
+}
+
+// cookieMAC binds the signature to the cookie name so a signed value can't
+// be replayed under another name.
+func cookieMAC(key []byte, name, encoded string) []byte {
+	mac := hmac.New(sha256.New, deriveCookieKey(key, "signing"))
+	mac.Write([]byte(name + "|" + encoded))
+	return mac.Sum(nil)
+}
+
+func cookieAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveCookieKey(key, "encryption"))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,139 @@
+package ron
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newCookieCTX(keys ...string) (*CTX, *httptest.ResponseRecorder) {
+	e := New()
+	for _, key := range keys {
+		e.Config.CookieKeys = append(e.Config.CookieKeys, []byte(key))
+	}
+	rr := httptest.NewRecorder()
+	return &CTX{
+		W: &responseWriterWrapper{ResponseWriter: rr},
+		R: httptest.NewRequest("GET", "/", nil),
+		E: e,
+	}, rr
+}
+
+// roundTrip returns a CTX whose request carries the cookies set on rr.
+func roundTrip(rr *httptest.ResponseRecorder, keys ...string) *CTX {
+	c, _ := newCookieCTX(keys...)
+	for _, cookie := range rr.Result().Cookies() {
+		c.R.AddCookie(cookie)
+	}
+	return c
+}
+
+func Test_SetCookie(t *testing.T) {
+	c, rr := newCookieCTX()
+	c.R.TLS = &tls.ConnectionState{}
+
+	if err := c.SetCookie("foo", "bar", func(cookie *http.Cookie) {
+		cookie.MaxAge = 60
+	}); err != nil {
+		t.Fatalf("SetCookie() failed: %v", err)
+	}
+
+	expected := "foo=bar; Path=/; Max-Age=60; HttpOnly; Secure; SameSite=Lax"
+	if actual := rr.Header().Get("Set-Cookie"); actual != expected {
+		t.Errorf("Expected: %q, Actual: %q", expected, actual)
+	}
+	if value, err := roundTrip(rr).Cookie("foo"); err != nil || value != "bar" {
+		t.Errorf("Expected: bar, Actual: %q, %v", value, err)
+	}
+	if err := c.SetCookie("big", strings.Repeat("x", maxCookieSize)); !errors.Is(err, ErrCookieTooLarge) {
+		t.Errorf("Expected: %v, Actual: %v", ErrCookieTooLarge, err)
+	}
+}
+
+func Test_SignedCookie(t *testing.T) {
+	tests := map[string]struct {
+		writeKeys   []string
+		readKeys    []string
+		tamper      func(*http.Cookie)
+		expectedErr error
+	}{
+		"same key": {
+			writeKeys: []string{"secret"},
+			readKeys:  []string{"secret"},
+		},
+		"rotated key": {
+			writeKeys: []string{"old"},
+			readKeys:  []string{"new", "old"},
+		},
+		"unknown key": {
+			writeKeys:   []string{"old"},
+			readKeys:    []string{"new"},
+			expectedErr: ErrInvalidCookie,
+		},
+		"tampered value": {
+			writeKeys: []string{"secret"},
+			readKeys:  []string{"secret"},
+			tamper: func(cookie *http.Cookie) {
+				cookie.Value = "YWRtaW4" + cookie.Value[strings.Index(cookie.Value, "."):]
+			},
+			expectedErr: ErrInvalidCookie,
+		},
+		"no keys": {
+			readKeys:    []string{},
+			expectedErr: ErrNoCookieKeys,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, rr := newCookieCTX(tt.writeKeys...)
+			c.SetSignedCookie("session", "user:42")
+
+			reader, _ := newCookieCTX(tt.readKeys...)
+			for _, cookie := range rr.Result().Cookies() {
+				if tt.tamper != nil {
+					tt.tamper(cookie)
+				}
+				reader.R.AddCookie(cookie)
+			}
+
+			value, err := reader.SignedCookie("session")
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error: %v, Actual: %v", tt.expectedErr, err)
+			}
+			if err == nil && value != "user:42" {
+				t.Errorf("Expected: user:42, Actual: %q", value)
+			}
+		})
+	}
+}
+
+func Test_EncryptedCookie(t *testing.T) {
+	c, rr := newCookieCTX("new")
+	if err := c.SetEncryptedCookie("session", "user:42"); err != nil {
+		t.Fatalf("SetEncryptedCookie() failed: %v", err)
+	}
+	if strings.Contains(rr.Header().Get("Set-Cookie"), "user:42") {
+		t.Error("Expected the cookie value to be encrypted")
+	}
+
+	if value, err := roundTrip(rr, "newer", "new").EncryptedCookie("session"); err != nil || value != "user:42" {
+		t.Errorf("Expected: user:42, Actual: %q, %v", value, err)
+	}
+
+	// The name is authenticated, so the value can't be moved to another cookie.
+	renamed, _ := newCookieCTX("new")
+	for _, cookie := range rr.Result().Cookies() {
+		cookie.Name = "other"
+		renamed.R.AddCookie(cookie)
+	}
+	if _, err := renamed.EncryptedCookie("other"); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("Expected: %v, Actual: %v", ErrInvalidCookie, err)
+	}
+	if _, err := roundTrip(rr, "new").EncryptedCookie("missing"); !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("Expected: %v, Actual: %v", http.ErrNoCookie, err)
+	}
+}
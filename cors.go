@@ -0,0 +1,168 @@
+package ron
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures CORSMiddleware.
+type CORSConfig struct {
+	// AllowOrigins lists the origins allowed to make cross-origin requests,
+	// such as https://app.example.com. An origin may have one wildcard in
+	// place of its subdomains, as in https://*.example.com, which matches
+	// https://app.example.com and https://eu.app.example.com but not
+	// https://example.com. "*" allows every origin.
+	AllowOrigins []string
+	// AllowOriginFunc allows the origins it returns true for, in addition
+	// to AllowOrigins.
+	AllowOriginFunc func(origin string) bool
+	// AllowMethods are the methods allowed in preflight requests. They
+	// default to GET, POST and HEAD.
+	AllowMethods []string
+	// AllowHeaders are the request headers allowed in preflight requests.
+	// When empty, the headers asked for by the preflight are allowed.
+	AllowHeaders []string
+	// ExposeHeaders are the response headers scripts may read besides the
+	// CORS-safelisted ones.
+	ExposeHeaders []string
+	// AllowCredentials lets requests include cookies and HTTP
+	// authentication. It can't be combined with an AllowOrigins of "*".
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response. Zero
+	// leaves it to the browser and a negative value disables caching.
+	MaxAge time.Duration
+}
+
+// corsOrigin is an allowed origin, split around its wildcard if it has one.
+type corsOrigin struct {
+	prefix, suffix string
+	wildcard       bool
+}
+
+func (o corsOrigin) match(origin string) bool {
+	if !o.wildcard {
+		return origin == o.prefix
+	}
+	if len(origin) <= len(o.prefix)+len(o.suffix) ||
+		!strings.HasPrefix(origin, o.prefix) || !strings.HasSuffix(origin, o.suffix) {
+		return false
+	}
+	subdomain := origin[len(o.prefix) : len(origin)-len(o.suffix)]
+	return !strings.ContainsAny(subdomain, "/:@")
+}
+
+// CORSMiddleware lets browsers call the engine from the origins in config.
+// Preflight requests, OPTIONS requests with an
+// Access-Control-Request-Method header, are answered with 204 No Content
+// and don't reach the handlers. Other requests from an allowed origin get
+// the CORS headers and go on to the handlers; requests from other origins
+// go on without them, so the browser keeps the response from the script.
+//
+//	e.USE(e.CORSMiddleware(ron.CORSConfig{
+//		AllowOrigins:     []string{"https://app.example.com", "https://*.example.dev"},
+//		AllowHeaders:     []string{"Authorization", "Content-Type"},
+//		AllowCredentials: true,
+//		MaxAge:           time.Hour,
+//	}))
+//
+// It panics if AllowCredentials is set with an AllowOrigins of "*".
+func (e *Engine) CORSMiddleware(config CORSConfig) Middleware {
+	allowAll := false
+	var origins []corsOrigin
+	for _, origin := range config.AllowOrigins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			allowAll = true
+			continue
+		}
+		prefix, suffix, wildcard := strings.Cut(origin, "*")
+		origins = append(origins, corsOrigin{prefix: prefix, suffix: suffix, wildcard: wildcard})
+	}
+	if allowAll && config.AllowCredentials {
+		panic("ron: CORSMiddleware can't allow credentials from every origin")
+	}
+
+	methods := strings.Join(config.AllowMethods, ", ")
+	if methods == "" {
+		methods = "GET, POST, HEAD"
+	}
+	headers := strings.Join(config.AllowHeaders, ", ")
+	exposed := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := ""
+	if config.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(config.MaxAge / time.Second))
+	} else if config.MaxAge < 0 {
+		maxAge = "0"
+	}
+
+	allowed := func(origin string) bool {
+		if allowAll {
+			return true
+		}
+		lower := strings.ToLower(origin)
+		for _, o := range origins {
+			if o.match(lower) {
+				return true
+			}
+		}
+		return config.AllowOriginFunc != nil && config.AllowOriginFunc(origin)
+	}
+	// Responses depend on the Origin header unless every origin gets the
+	// same "*".
+	varyOrigin := !allowAll
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if varyOrigin {
+				h.Add("Vary", "Origin")
+			}
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !allowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if varyOrigin {
+				h.Set("Access-Control-Allow-Origin", origin)
+			} else {
+				h.Set("Access-Control-Allow-Origin", "*")
+			}
+			if config.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposed != "" {
+					h.Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				h.Set("Access-Control-Allow-Headers", headers)
+			} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				h.Set("Access-Control-Allow-Headers", requested)
+			}
+			if maxAge != "" {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package ron
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_CORSMiddleware(t *testing.T) {
+	config := CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.dev"},
+		AllowOriginFunc:  func(origin string) bool { return origin == "http://localhost:3000" },
+		AllowHeaders:     []string{"Authorization", "Content-Type"},
+		ExposeHeaders:    []string{"X-Total-Count"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}
+
+	tests := map[string]struct {
+		givenConfig      CORSConfig
+		givenMethod      string
+		givenHeaders     map[string]string
+		expectedCode     int
+		expectedBody     string
+		expectedHeaders  map[string]string
+		expectedVary     []string
+		expectedNoHeader []string
+	}{
+		"same origin request": {
+			givenConfig:      config,
+			givenMethod:      "GET",
+			expectedCode:     http.StatusOK,
+			expectedBody:     "handler",
+			expectedVary:     []string{"Origin"},
+			expectedNoHeader: []string{"Access-Control-Allow-Origin"},
+		},
+		"allowed origin": {
+			givenConfig:  config,
+			givenMethod:  "GET",
+			givenHeaders: map[string]string{"Origin": "https://app.example.com"},
+			expectedCode: http.StatusOK,
+			expectedBody: "handler",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Total-Count",
+			},
+			expectedVary:     []string{"Origin"},
+			expectedNoHeader: []string{"Access-Control-Allow-Methods"},
+		},
+		"wildcard subdomain": {
+			givenConfig:     config,
+			givenMethod:     "POST",
+			givenHeaders:    map[string]string{"Origin": "https://eu.app.example.dev"},
+			expectedCode:    http.StatusOK,
+			expectedBody:    "handler",
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": "https://eu.app.example.dev"},
+		},
+		"wildcard does not match the bare domain": {
+			givenConfig:      config,
+			givenMethod:      "GET",
+			givenHeaders:     map[string]string{"Origin": "https://example.dev"},
+			expectedCode:     http.StatusOK,
+			expectedBody:     "handler",
+			expectedNoHeader: []string{"Access-Control-Allow-Origin"},
+		},
+		"wildcard does not match another domain": {
+			givenConfig:      config,
+			givenMethod:      "GET",
+			givenHeaders:     map[string]string{"Origin": "https://evil.com/.example.dev"},
+			expectedCode:     http.StatusOK,
+			expectedBody:     "handler",
+			expectedNoHeader: []string{"Access-Control-Allow-Origin"},
+		},
+		"origin predicate": {
+			givenConfig:     config,
+			givenMethod:     "GET",
+			givenHeaders:    map[string]string{"Origin": "http://localhost:3000"},
+			expectedCode:    http.StatusOK,
+			expectedBody:    "handler",
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": "http://localhost:3000"},
+		},
+		"disallowed origin": {
+			givenConfig:      config,
+			givenMethod:      "GET",
+			givenHeaders:     map[string]string{"Origin": "https://evil.com"},
+			expectedCode:     http.StatusOK,
+			expectedBody:     "handler",
+			expectedVary:     []string{"Origin"},
+			expectedNoHeader: []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials"},
+		},
+		"preflight": {
+			givenConfig: config,
+			givenMethod: "OPTIONS",
+			givenHeaders: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type",
+			},
+			expectedCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST, HEAD",
+				"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+				"Access-Control-Max-Age":           "3600",
+			},
+			expectedVary:     []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			expectedNoHeader: []string{"Access-Control-Expose-Headers"},
+		},
+		"preflight from a disallowed origin": {
+			givenConfig: config,
+			givenMethod: "OPTIONS",
+			givenHeaders: map[string]string{
+				"Origin":                        "https://evil.com",
+				"Access-Control-Request-Method": "POST",
+			},
+			expectedCode:     http.StatusNoContent,
+			expectedNoHeader: []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Methods"},
+		},
+		"preflight reflects requested headers": {
+			givenConfig: CORSConfig{AllowOrigins: []string{"*"}, AllowMethods: []string{"GET", "POST", "PUT"}, MaxAge: -1},
+			givenMethod: "OPTIONS",
+			givenHeaders: map[string]string{
+				"Origin":                         "https://anywhere.com",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "x-custom",
+			},
+			expectedCode: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Methods": "GET, POST, PUT",
+				"Access-Control-Allow-Headers": "x-custom",
+				"Access-Control-Max-Age":       "0",
+			},
+			expectedVary:     []string{"Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			expectedNoHeader: []string{"Access-Control-Allow-Credentials"},
+		},
+		"any origin": {
+			givenConfig:      CORSConfig{AllowOrigins: []string{"*"}},
+			givenMethod:      "GET",
+			givenHeaders:     map[string]string{"Origin": "https://anywhere.com"},
+			expectedCode:     http.StatusOK,
+			expectedBody:     "handler",
+			expectedHeaders:  map[string]string{"Access-Control-Allow-Origin": "*"},
+			expectedNoHeader: []string{"Vary"},
+		},
+		"options without preflight headers": {
+			givenConfig:  config,
+			givenMethod:  "OPTIONS",
+			givenHeaders: map[string]string{"Origin": "https://app.example.com"},
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := New()
+			e.USE(e.CORSMiddleware(tt.givenConfig))
+			e.GET("/", func(c *CTX, ctx context.Context) {
+				c.W.Write([]byte("handler"))
+			})
+			e.POST("/", func(c *CTX, ctx context.Context) {
+				c.W.Write([]byte("handler"))
+			})
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.givenMethod, "/", nil)
+			for k, v := range tt.givenHeaders {
+				req.Header.Set(k, v)
+			}
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectedBody != "" && rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body: %s, Actual: %s", tt.expectedBody, rr.Body.String())
+			}
+			for k, v := range tt.expectedHeaders {
+				if actual := rr.Header().Get(k); actual != v {
+					t.Errorf("Expected %s: %s, Actual: %s", k, v, actual)
+				}
+			}
+			if tt.expectedVary != nil {
+				if vary := rr.Header().Values("Vary"); strings.Join(vary, ",") != strings.Join(tt.expectedVary, ",") {
+					t.Errorf("Expected Vary: %v, Actual: %v", tt.expectedVary, vary)
+				}
+			}
+			for _, k := range tt.expectedNoHeader {
+				if actual := rr.Header().Get(k); actual != "" {
+					t.Errorf("Expected no %s, Actual: %s", k, actual)
+				}
+			}
+		})
+	}
+}
+
+func Test_CORSMiddlewareCredentialsWithAnyOrigin(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	New().CORSMiddleware(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+}
@@ -0,0 +1,105 @@
+package ron
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+type (
+	// HTTPError is an error that carries the status code it should be
+	// rendered with.
+	HTTPError struct {
+		Code int
+		Err  error
+	}
+
+	// ParamError reports a request parameter that is present but can't be
+	// parsed. It renders as 400 Bad Request.
+	ParamError struct {
+		Source string
+		Key    string
+		Value  string
+		Err    error
+	}
+)
+
+func (e *HTTPError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+func (e *HTTPError) StatusCode() int {
+	return e.Code
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("invalid %s parameter %q: %v", e.Source, e.Key, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ParamError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// StatusCode returns the status an error should be rendered with: the one
+// reported by the first error in the chain with a StatusCode method, or 500.
+func StatusCode(err error) int {
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) {
+		return sc.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// Error renders err through the engine's ErrorHandler.
+func (c *CTX) Error(err error) {
+	if c.E != nil && c.E.ErrorHandler != nil {
+		c.E.ErrorHandler(c, err)
+		return
+	}
+	defaultErrorHandler(c, err)
+}
+
+// writeError renders err from a middleware: through the engine's
+// ErrorHandler when r is served by an Engine, and as plain text otherwise.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	c := FromContext(r.Context())
+	if c == nil {
+		http.Error(w, err.Error(), StatusCode(err))
+		return
+	}
+	c.W = newResponseWriter(w)
+	c.R = r
+	c.Error(err)
+}
+
+// defaultErrorHandler writes the error as a Problem to clients preferring
+// JSON and as plain text to the others. Server errors are logged and
+// replaced by the status text so internals don't leak.
+func defaultErrorHandler(c *CTX, err error) {
+	code := StatusCode(err)
+	if code >= http.StatusInternalServerError {
+		slog.Error("request failed", "error", err, "path", c.R.URL.Path)
+	}
+	if wantsProblem(c.R) {
+		c.Problem(NewProblem(err))
+		return
+	}
+
+	msg := err.Error()
+	if code >= http.StatusInternalServerError {
+		msg = http.StatusText(code)
+	}
+	http.Error(c.W, msg, code)
+}
@@ -0,0 +1,93 @@
+package ron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"ron/testhelpers"
+	"testing"
+)
+
+func Test_StatusCode(t *testing.T) {
+	tests := map[string]struct {
+		givenErr     error
+		expectedCode int
+	}{
+		"plain error":   {errors.New("foo"), http.StatusInternalServerError},
+		"http error":    {&HTTPError{Code: http.StatusNotFound}, http.StatusNotFound},
+		"param error":   {&ParamError{Source: "query", Key: "id"}, http.StatusBadRequest},
+		"wrapped error": {fmt.Errorf("wrap: %w", &HTTPError{Code: http.StatusConflict}), http.StatusConflict},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if code := StatusCode(tt.givenErr); code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, code)
+			}
+		})
+	}
+}
+
+func Test_Error(t *testing.T) {
+	tests := map[string]struct {
+		givenErr         error
+		expectedResponse testhelpers.ExpectedResponse
+	}{
+		"client error": {
+			givenErr: &ParamError{Source: "query", Key: "page", Value: "x", Err: errors.New("not a number")},
+			expectedResponse: testhelpers.ExpectedResponse{
+				Code:   http.StatusBadRequest,
+				Header: HeaderPlain_UTF8,
+				Body:   "invalid query parameter \"page\": not a number\n",
+			},
+		},
+		"server error": {
+			givenErr: errors.New("database is down"),
+			expectedResponse: testhelpers.ExpectedResponse{
+				Code:   http.StatusInternalServerError,
+				Header: HeaderPlain_UTF8,
+				Body:   "Internal Server Error\n",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := New()
+			e.GET("/", func(c *CTX, ctx context.Context) {
+				c.Error(tt.givenErr)
+			})
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/", nil)
+			e.ServeHTTP(rr, req)
+
+			testhelpers.VerifyResponse(t, rr, tt.expectedResponse)
+		})
+	}
+}
+
+func Test_ErrorHandler(t *testing.T) {
+	e := New(func(e *Engine) {
+		e.ErrorHandler = func(c *CTX, err error) {
+			c.W.Header().Set("Content-Type", HeaderPlain_UTF8)
+			c.W.WriteHeader(StatusCode(err))
+			c.W.Write([]byte("custom: " + err.Error()))
+		}
+	})
+	e.GET("/", func(c *CTX, ctx context.Context) {
+		c.Error(&HTTPError{Code: http.StatusTeapot})
+	})
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	e.ServeHTTP(rr, req)
+
+	testhelpers.VerifyResponse(t, rr, testhelpers.ExpectedResponse{
+		Code:   http.StatusTeapot,
+		Header: HeaderPlain_UTF8,
+		Body:   "custom: I'm a teapot",
+	})
+}
@@ -0,0 +1,123 @@
+package ron
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Form holds the submitted values of a form and a message for every field
+// that was rejected, so a page can be rendered again with the user's input
+// after a failed bind or validation:
+//
+//	if err := c.BindForm(&signup); err != nil {
+//		c.HTML(http.StatusBadRequest, "page.signup.gohtml", &TemplateData{Form: c.Form(err)})
+//		return
+//	}
+//
+// and in the template:
+//
+//	<input name="email" value="{{ .Form.Value "email" }}">
+//	{{ if .Form.HasError "email" }}<p>{{ .Form.Error "email" }}</p>{{ end }}
+//
+// Keys may use dot or bracket notation interchangeably: items.0.qty and
+// items[0].qty name the same field. A nil Form has no values and no errors,
+// so templates can call its methods on a first render.
+type Form struct {
+	values   map[string][]string
+	messages map[string][]string
+}
+
+// NewForm builds a Form from the submitted values and the error returned by
+// a bind or by Validate. BindingErrors, ValidationErrors and ParamError add
+// messages to their fields; other errors add none.
+func NewForm(values url.Values, err error) *Form {
+	f := &Form{
+		values:   make(map[string][]string, len(values)),
+		messages: make(map[string][]string),
+	}
+	for key, v := range values {
+		f.values[formPath(key)] = v
+	}
+
+	var bindingErrs BindingErrors
+	var validationErrs ValidationErrors
+	var paramErr *ParamError
+	switch {
+	case errors.As(err, &bindingErrs):
+		for _, be := range bindingErrs {
+			f.addMessage(be.Key, be.Reason)
+		}
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			f.addMessage(fe.Key, fe.Message())
+		}
+	case errors.As(err, &paramErr):
+		f.addMessage(paramErr.Key, paramErr.Err.Error())
+	}
+	return f
+}
+
+// Form builds a Form from the request form, the query string and the body
+// together, and the error returned by a bind or by Validate.
+func (c *CTX) Form(err error) *Form {
+	if c.R.Form == nil {
+		c.parseMultipartForm()
+	}
+	return NewForm(c.R.Form, err)
+}
+
+func (f *Form) addMessage(key, msg string) {
+	path := formPath(key)
+	f.messages[path] = append(f.messages[path], msg)
+}
+
+// Value returns the first value submitted for key.
+func (f *Form) Value(key string) string {
+	if f == nil {
+		return ""
+	}
+	if v := f.values[formPath(key)]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Values returns every value submitted for key, as for a multiple select.
+func (f *Form) Values(key string) []string {
+	if f == nil {
+		return nil
+	}
+	return f.values[formPath(key)]
+}
+
+// Error returns the first message for key.
+func (f *Form) Error(key string) string {
+	if msgs := f.Errors(key); len(msgs) > 0 {
+		return msgs[0]
+	}
+	return ""
+}
+
+// Errors returns every message for key.
+func (f *Form) Errors(key string) []string {
+	if f == nil {
+		return nil
+	}
+	return f.messages[formPath(key)]
+}
+
+// HasError reports whether key has a message.
+func (f *Form) HasError(key string) bool {
+	return len(f.Errors(key)) > 0
+}
+
+// Valid reports whether no field has a message.
+func (f *Form) Valid() bool {
+	return f == nil || len(f.messages) == 0
+}
+
+// formPath normalizes a key so both notations of a nested key match.
+func formPath(key string) string {
+	return strings.Join(splitFormKey(key), "\x00")
+}
@@ -0,0 +1,99 @@
+package ron
+
+import (
+	"bytes"
+	"html/template"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func Test_Form(t *testing.T) {
+	type signup struct {
+		Email string `form:"email" validate:"required,email"`
+		Age   int    `form:"age" validate:"gte=18"`
+		Items []Item `form:"items"`
+	}
+
+	tests := map[string]struct {
+		form           url.Values
+		expectedErrors map[string]string
+	}{
+		"binding errors": {
+			form: url.Values{"email": {"foo@example.com"}, "age": {"old"}, "items[0].qty": {"x"}},
+			expectedErrors: map[string]string{
+				"age":          "must be a whole number",
+				"items.0.qty":  "must be a whole number",
+				"items[0].qty": "must be a whole number",
+			},
+		},
+		"validation errors": {
+			form: url.Values{"email": {"foo"}, "age": {"12"}},
+			expectedErrors: map[string]string{
+				"email": "must be a valid email address",
+				"age":   "must be greater than or equal to 18",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			c := &CTX{R: req, E: New(func(e *Engine) {
+				e.Config.Binding.Validate = true
+			})}
+
+			form := c.Form(c.BindForm(&signup{}))
+
+			if form.Valid() {
+				t.Fatal("Expected invalid form")
+			}
+			for key, msg := range tt.expectedErrors {
+				if !form.HasError(key) || form.Error(key) != msg {
+					t.Errorf("Expected %s error: %q, Actual: %q", key, msg, form.Error(key))
+				}
+			}
+			for key := range tt.form {
+				if form.Value(key) != tt.form.Get(key) {
+					t.Errorf("Expected %s value: %q, Actual: %q", key, tt.form.Get(key), form.Value(key))
+				}
+			}
+		})
+	}
+}
+
+func Test_FormTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("form").Parse(
+		`<input name="age" value="{{ .Form.Value "age" }}">{{ if .Form.HasError "age" }}<p>{{ .Form.Error "age" }}</p>{{ end }}`,
+	))
+
+	tests := map[string]struct {
+		data     *TemplateData
+		expected string
+	}{
+		"first render": {
+			data:     &TemplateData{},
+			expected: `<input name="age" value="">`,
+		},
+		"render again": {
+			data: &TemplateData{Form: NewForm(url.Values{"age": {"<old>"}}, BindingErrors{
+				{Source: "form", Field: "Age", Key: "age", Value: "<old>", Reason: "must be a whole number"},
+			})},
+			expected: `<input name="age" value="&lt;old&gt;"><p>must be a whole number</p>`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, tt.data); err != nil {
+				t.Fatalf("Execute() failed: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Expected: %s, Actual: %s", tt.expected, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+// Package jwt signs and verifies JSON Web Tokens, RFC 7519, with HS256,
+// RS256, ES256 and EdDSA, using only the standard library. Claims are
+// typed: a claims struct embeds RegisteredClaims and adds its own fields.
+//
+//	type Claims struct {
+//		jwt.RegisteredClaims
+//		Role string `json:"role"`
+//	}
+//
+//	token, err := keys.Sign(Claims{
+//		RegisteredClaims: jwt.RegisteredClaims{
+//			Subject:   user.ID,
+//			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+//		},
+//		Role: "admin",
+//	})
+//
+//	claims, err := jwt.Verify[Claims](token, jwt.VerifyConfig{Keys: keys})
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// RegisteredClaims are the claims registered by RFC 7519. Claims
+	// structs embed it to satisfy Claims.
+	RegisteredClaims struct {
+		Issuer    string       `json:"iss,omitempty"`
+		Subject   string       `json:"sub,omitempty"`
+		Audience  Audience     `json:"aud,omitempty"`
+		ExpiresAt *NumericDate `json:"exp,omitempty"`
+		NotBefore *NumericDate `json:"nbf,omitempty"`
+		IssuedAt  *NumericDate `json:"iat,omitempty"`
+		ID        string       `json:"jti,omitempty"`
+	}
+
+	// Claims is implemented by every struct embedding RegisteredClaims.
+	Claims interface {
+		Registered() RegisteredClaims
+	}
+
+	// Audience is the aud claim. It is written as a string when it has a
+	// single value and read from a string or an array.
+	Audience []string
+
+	// NumericDate is a time written as seconds since the Unix epoch.
+	NumericDate struct {
+		time.Time
+	}
+
+	// VerifyConfig configures Verify.
+	VerifyConfig struct {
+		// Keys holds the keys tokens may be signed with. A token's alg must
+		// be the algorithm of the key that verifies it.
+		Keys *KeySet
+		// Algorithms restricts the algorithms accepted. All are accepted
+		// when it is empty.
+		Algorithms []string
+		// Issuer, when set, must be the iss claim.
+		Issuer string
+		// Audience, when set, must be in the aud claim.
+		Audience string
+		// RequireExpiry rejects tokens without an exp claim.
+		RequireExpiry bool
+		// ClockSkew is the leeway given to the exp, nbf and iat checks for
+		// clocks that disagree.
+		ClockSkew time.Duration
+		// Now replaces time.Now, for tests.
+		Now func() time.Time
+	}
+
+	header struct {
+		Alg  string   `json:"alg"`
+		Typ  string   `json:"typ,omitempty"`
+		Kid  string   `json:"kid,omitempty"`
+		Crit []string `json:"crit,omitempty"`
+	}
+)
+
+var (
+	ErrMalformed        = errors.New("jwt: malformed token")
+	ErrUnsupportedAlg   = errors.New("jwt: unsupported algorithm")
+	ErrUnknownKey       = errors.New("jwt: no key for the token")
+	ErrInvalidSignature = errors.New("jwt: invalid signature")
+	ErrExpired          = errors.New("jwt: token has expired")
+	ErrMissingExpiry    = errors.New("jwt: token has no expiry")
+	ErrNotYetValid      = errors.New("jwt: token is not valid yet")
+	ErrIssuedInFuture   = errors.New("jwt: token issued in the future")
+	ErrInvalidIssuer    = errors.New("jwt: invalid issuer")
+	ErrInvalidAudience  = errors.New("jwt: invalid audience")
+)
+
+// Registered returns the registered claims, which makes every struct
+// embedding RegisteredClaims a Claims.
+func (c RegisteredClaims) Registered() RegisteredClaims {
+	return c
+}
+
+// NewNumericDate returns t truncated to the second.
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{t.Truncate(time.Second)}
+}
+
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, d.Unix(), 10), nil
+}
+
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var seconds json.Number
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("jwt: invalid date %s", data)
+	}
+	f, err := seconds.Float64()
+	if err != nil {
+		return fmt.Errorf("jwt: invalid date %s", data)
+	}
+	d.Time = time.Unix(int64(f), 0)
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("jwt: aud must be a string or an array of strings")
+	}
+	*a = many
+	return nil
+}
+
+// Sign signs claims with the key that signs for the set: the last one
+// added that has a secret or a private key.
+func (ks *KeySet) Sign(claims any) (string, error) {
+	key := ks.signingKey()
+	if key == nil {
+		return "", errors.New("jwt: no key that can sign")
+	}
+	return Sign(claims, key)
+}
+
+// Sign encodes claims as a token signed with key, whose ID goes in the kid
+// header.
+func Sign(claims any, key *Key) (string, error) {
+	if !key.canSign() {
+		return "", fmt.Errorf("jwt: key %q can only verify", key.ID)
+	}
+	h, err := json.Marshal(header{Alg: key.Algorithm, Typ: "JWT", Kid: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodeSegment(h) + "." + encodeSegment(payload)
+	signature, err := key.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// Verify checks the signature of token and its exp, nbf, iat, iss and aud
+// claims, and returns its claims. Errors wrap one of the Err variables.
+func Verify[C Claims](token string, config VerifyConfig) (C, error) {
+	var claims C
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrMalformed
+	}
+	var h header
+	if err := decodeJSONSegment(parts[0], &h); err != nil {
+		return claims, err
+	}
+	if len(h.Crit) > 0 {
+		return claims, fmt.Errorf("%w: unsupported critical headers %v", ErrMalformed, h.Crit)
+	}
+	if !supportedAlg(h.Alg) || (len(config.Algorithms) > 0 && !slices.Contains(config.Algorithms, h.Alg)) {
+		return claims, fmt.Errorf("%w: %q", ErrUnsupportedAlg, h.Alg)
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return claims, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	if config.Keys == nil {
+		return claims, ErrUnknownKey
+	}
+	keys := config.Keys.candidates(h.Kid, h.Alg)
+	if len(keys) == 0 {
+		return claims, ErrUnknownKey
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if key.verify(signingInput, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return claims, ErrInvalidSignature
+	}
+
+	if err := decodeJSONSegment(parts[1], &claims); err != nil {
+		return *new(C), err
+	}
+	// A null payload leaves a pointer C nil.
+	if v := reflect.ValueOf(claims); !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return *new(C), fmt.Errorf("%w: no claims", ErrMalformed)
+	}
+	if err := config.check(claims.Registered()); err != nil {
+		return *new(C), err
+	}
+	return claims, nil
+}
+
+// check validates the registered claims against config.
+func (config VerifyConfig) check(rc RegisteredClaims) error {
+	now := time.Now()
+	if config.Now != nil {
+		now = config.Now()
+	}
+	skew := config.ClockSkew
+
+	switch {
+	case rc.ExpiresAt == nil && config.RequireExpiry:
+		return ErrMissingExpiry
+	case rc.ExpiresAt != nil && !now.Before(rc.ExpiresAt.Add(skew)):
+		return ErrExpired
+	case rc.NotBefore != nil && now.Add(skew).Before(rc.NotBefore.Time):
+		return ErrNotYetValid
+	case rc.IssuedAt != nil && now.Add(skew).Before(rc.IssuedAt.Time):
+		return ErrIssuedInFuture
+	case config.Issuer != "" && rc.Issuer != config.Issuer:
+		return ErrInvalidIssuer
+	case config.Audience != "" && !slices.Contains(rc.Audience, config.Audience):
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+func supportedAlg(alg string) bool {
+	switch alg {
+	case HS256, RS256, ES256, EdDSA:
+		return true
+	}
+	return false
+}
+
+func decodeJSONSegment(segment string, v any) error {
+	data, err := decodeSegment(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
+
+func (k *Key) sign(signingInput []byte) ([]byte, error) {
+	digest := sha256.Sum256(signingInput)
+	switch k.Algorithm {
+	case HS256:
+		m := hmac.New(sha256.New, k.secret)
+		m.Write(signingInput)
+		return m.Sum(nil), nil
+	case RS256:
+		return rsa.SignPKCS1v15(rand.Reader, k.private.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case ES256:
+		r, s, err := ecdsa.Sign(rand.Reader, k.private.(*ecdsa.PrivateKey), digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS uses the fixed-width R || S form rather than ASN.1.
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature, nil
+	case EdDSA:
+		return ed25519.Sign(k.private.(ed25519.PrivateKey), signingInput), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, k.Algorithm)
+}
+
+func (k *Key) verify(signingInput, signature []byte) bool {
+	digest := sha256.Sum256(signingInput)
+	switch k.Algorithm {
+	case HS256:
+		m := hmac.New(sha256.New, k.secret)
+		m.Write(signingInput)
+		return hmac.Equal(signature, m.Sum(nil))
+	case RS256:
+		return rsa.VerifyPKCS1v15(k.public.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	case ES256:
+		if len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(k.public.(*ecdsa.PublicKey), digest[:], r, s)
+	case EdDSA:
+		return ed25519.Verify(k.public.(ed25519.PublicKey), signingInput, signature)
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testClaims struct {
+	RegisteredClaims
+	Role string `json:"role"`
+}
+
+var (
+	testKeysOnce sync.Once
+	testKeyList  []*Key
+)
+
+// testKeys returns one signing key per algorithm, generated once as RSA
+// keys are slow to make.
+func testKeys(t *testing.T) []*Key {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+		for id, key := range map[string]any{
+			"hs":  []byte(strings.Repeat("k", 32)),
+			"rsa": rsaKey,
+			"ec":  ecKey,
+			"ed":  edKey,
+		} {
+			k, err := NewKey(id, key)
+			if err != nil {
+				panic(err)
+			}
+			testKeyList = append(testKeyList, k)
+		}
+	})
+	return testKeyList
+}
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func Test_SignVerify(t *testing.T) {
+	for _, key := range testKeys(t) {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			claims := testClaims{
+				RegisteredClaims: RegisteredClaims{
+					Issuer:    "ron",
+					Subject:   "42",
+					Audience:  Audience{"api"},
+					ExpiresAt: NewNumericDate(testNow.Add(time.Hour)),
+					IssuedAt:  NewNumericDate(testNow),
+				},
+				Role: "admin",
+			}
+			token, err := Sign(claims, key)
+			if err != nil {
+				t.Fatalf("Sign() failed: %v", err)
+			}
+
+			var h header
+			if err := decodeJSONSegment(strings.Split(token, ".")[0], &h); err != nil {
+				t.Fatal(err)
+			}
+			if h.Alg != key.Algorithm || h.Kid != key.ID || h.Typ != "JWT" {
+				t.Errorf("Expected header for %s %s, Actual: %+v", key.Algorithm, key.ID, h)
+			}
+
+			actual, err := Verify[testClaims](token, VerifyConfig{
+				Keys:     NewKeySet(testKeys(t)...),
+				Issuer:   "ron",
+				Audience: "api",
+				Now:      func() time.Time { return testNow },
+			})
+			if err != nil {
+				t.Fatalf("Verify() failed: %v", err)
+			}
+			if actual.Subject != "42" || actual.Role != "admin" || !actual.ExpiresAt.Equal(claims.ExpiresAt.Time) {
+				t.Errorf("Expected: %+v, Actual: %+v", claims, actual)
+			}
+		})
+	}
+}
+
+func Test_VerifyErrors(t *testing.T) {
+	keys := testKeys(t)
+	hs := keys[0]
+	for _, k := range keys {
+		if k.Algorithm == HS256 {
+			hs = k
+		}
+	}
+	other, _ := NewKey("other", []byte(strings.Repeat("x", 32)))
+	set := NewKeySet(keys...)
+
+	sign := func(claims any, key *Key) string {
+		token, err := Sign(claims, key)
+		if err != nil {
+			t.Fatalf("Sign() failed: %v", err)
+		}
+		return token
+	}
+	at := func(d time.Duration) *NumericDate { return NewNumericDate(testNow.Add(d)) }
+	valid := sign(testClaims{}, hs)
+	parts := strings.Split(valid, ".")
+
+	tests := map[string]struct {
+		givenToken    string
+		givenConfig   VerifyConfig
+		expectedError error
+	}{
+		"valid": {
+			givenToken: valid,
+		},
+		"not a token": {
+			givenToken:    "abc",
+			expectedError: ErrMalformed,
+		},
+		"bad header": {
+			givenToken:    "!!." + parts[1] + "." + parts[2],
+			expectedError: ErrMalformed,
+		},
+		"alg none": {
+			givenToken:    encodeSegment([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".",
+			expectedError: ErrUnsupportedAlg,
+		},
+		"alg not allowed": {
+			givenToken:    valid,
+			givenConfig:   VerifyConfig{Algorithms: []string{RS256}},
+			expectedError: ErrUnsupportedAlg,
+		},
+		"alg confusion": {
+			// An HS256 token claiming the kid of the RSA key.
+			givenToken:    encodeSegment([]byte(`{"alg":"HS256","kid":"rsa"}`)) + "." + parts[1] + "." + parts[2],
+			expectedError: ErrUnknownKey,
+		},
+		"unknown kid": {
+			givenToken:    sign(testClaims{}, other),
+			expectedError: ErrUnknownKey,
+		},
+		"tampered payload": {
+			givenToken:    parts[0] + "." + encodeSegment([]byte(`{"role":"admin"}`)) + "." + parts[2],
+			expectedError: ErrInvalidSignature,
+		},
+		"critical header": {
+			givenToken:    encodeSegment([]byte(`{"alg":"HS256","crit":["exp"]}`)) + "." + parts[1] + "." + parts[2],
+			expectedError: ErrMalformed,
+		},
+		"expired": {
+			givenToken:    sign(RegisteredClaims{ExpiresAt: at(-time.Second)}, hs),
+			expectedError: ErrExpired,
+		},
+		"expired within skew": {
+			givenToken:  sign(RegisteredClaims{ExpiresAt: at(-time.Second)}, hs),
+			givenConfig: VerifyConfig{ClockSkew: time.Minute},
+		},
+		"missing expiry": {
+			givenToken:    valid,
+			givenConfig:   VerifyConfig{RequireExpiry: true},
+			expectedError: ErrMissingExpiry,
+		},
+		"not yet valid": {
+			givenToken:    sign(RegisteredClaims{NotBefore: at(time.Minute)}, hs),
+			expectedError: ErrNotYetValid,
+		},
+		"not yet valid within skew": {
+			givenToken:  sign(RegisteredClaims{NotBefore: at(time.Minute)}, hs),
+			givenConfig: VerifyConfig{ClockSkew: 2 * time.Minute},
+		},
+		"issued in the future": {
+			givenToken:    sign(RegisteredClaims{IssuedAt: at(time.Hour)}, hs),
+			expectedError: ErrIssuedInFuture,
+		},
+		"wrong issuer": {
+			givenToken:    sign(RegisteredClaims{Issuer: "evil"}, hs),
+			givenConfig:   VerifyConfig{Issuer: "ron"},
+			expectedError: ErrInvalidIssuer,
+		},
+		"wrong audience": {
+			givenToken:    sign(RegisteredClaims{Audience: Audience{"web", "admin"}}, hs),
+			givenConfig:   VerifyConfig{Audience: "api"},
+			expectedError: ErrInvalidAudience,
+		},
+		"one of the audiences": {
+			givenToken:  sign(RegisteredClaims{Audience: Audience{"web", "api"}}, hs),
+			givenConfig: VerifyConfig{Audience: "api"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := tt.givenConfig
+			config.Keys = set
+			config.Now = func() time.Time { return testNow }
+			_, err := Verify[testClaims](tt.givenToken, config)
+			if !errors.Is(err, tt.expectedError) || (tt.expectedError == nil && err != nil) {
+				t.Errorf("Expected error: %v, Actual: %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func Test_VerifyNullClaims(t *testing.T) {
+	key, _ := NewKey("k", []byte(strings.Repeat("k", 32)))
+	keys := NewKeySet(key)
+
+	null, _ := Sign(nil, key)
+	if claims, err := Verify[*testClaims](null, VerifyConfig{Keys: keys}); !errors.Is(err, ErrMalformed) || claims != nil {
+		t.Errorf("Expected error: %v, Actual: %v, %v", ErrMalformed, claims, err)
+	}
+
+	token, _ := Sign(testClaims{Role: "admin"}, key)
+	if claims, err := Verify[*testClaims](token, VerifyConfig{Keys: keys}); err != nil || claims.Role != "admin" {
+		t.Errorf("Expected pointer claims, Actual: %+v, %v", claims, err)
+	}
+}
+
+func Test_VerifyWithoutKid(t *testing.T) {
+	a, _ := NewKey("", []byte(strings.Repeat("a", 32)))
+	b, _ := NewKey("", []byte(strings.Repeat("b", 32)))
+	token, _ := Sign(RegisteredClaims{Subject: "1"}, b)
+
+	claims, err := Verify[RegisteredClaims](token, VerifyConfig{Keys: NewKeySet(a, b)})
+	if err != nil || claims.Subject != "1" {
+		t.Errorf("Expected every key of the algorithm to be tried, Actual: %+v, %v", claims, err)
+	}
+}
+
+func Test_ClaimsJSON(t *testing.T) {
+	tests := map[string]struct {
+		givenJSON         string
+		expectedAudience  Audience
+		expectedExpiresAt int64
+	}{
+		"single audience": {`{"aud":"api","exp":1704110400}`, Audience{"api"}, 1704110400},
+		"many audiences":  {`{"aud":["api","web"]}`, Audience{"api", "web"}, 0},
+		"fractional date": {`{"exp":1704110400.75}`, nil, 1704110400},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var claims RegisteredClaims
+			if err := json.Unmarshal([]byte(tt.givenJSON), &claims); err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			if strings.Join(claims.Audience, ",") != strings.Join(tt.expectedAudience, ",") {
+				t.Errorf("Expected audience: %v, Actual: %v", tt.expectedAudience, claims.Audience)
+			}
+			if tt.expectedExpiresAt != 0 && claims.ExpiresAt.Unix() != tt.expectedExpiresAt {
+				t.Errorf("Expected exp: %d, Actual: %d", tt.expectedExpiresAt, claims.ExpiresAt.Unix())
+			}
+		})
+	}
+
+	data, _ := json.Marshal(RegisteredClaims{Audience: Audience{"api"}, ExpiresAt: NewNumericDate(testNow)})
+	if string(data) != `{"aud":"api","exp":1704110400}` {
+		t.Errorf("Expected compact claims, Actual: %s", data)
+	}
+}
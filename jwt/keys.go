@@ -0,0 +1,413 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+
+	"ron"
+)
+
+// Algorithms supported for signing and verifying.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// minRSABits is the smallest RSA modulus accepted, as required by RFC 7518.
+const minRSABits = 2048
+
+type (
+	// Key is a key for one algorithm, identified by ID in the kid header
+	// of the tokens it signs. A key built from a public key can only
+	// verify.
+	Key struct {
+		ID        string
+		Algorithm string
+
+		secret  []byte
+		private crypto.Signer
+		public  crypto.PublicKey
+	}
+
+	// KeySet holds the keys tokens are signed and verified with. Keys are
+	// rotated by adding the new key, which then signs, and removing the
+	// old one once the tokens it signed have expired. It is safe for
+	// concurrent use.
+	KeySet struct {
+		mu   sync.RWMutex
+		keys []*Key
+	}
+
+	// jwk is a JSON Web Key, RFC 7517, with the members of the key types
+	// supported.
+	jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid,omitempty"`
+		Alg string `json:"alg,omitempty"`
+		Use string `json:"use,omitempty"`
+		Crv string `json:"crv,omitempty"`
+		K   string `json:"k,omitempty"`
+		N   string `json:"n,omitempty"`
+		E   string `json:"e,omitempty"`
+		X   string `json:"x,omitempty"`
+		Y   string `json:"y,omitempty"`
+	}
+
+	jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+)
+
+// NewKey returns a key for the algorithm matching key:
+//
+//   - []byte: HS256, with a secret of at least 32 bytes
+//   - *rsa.PrivateKey or *rsa.PublicKey: RS256, of at least 2048 bits
+//   - *ecdsa.PrivateKey or *ecdsa.PublicKey: ES256, on the P-256 curve
+//   - ed25519.PrivateKey or ed25519.PublicKey: EdDSA
+func NewKey(id string, key any) (*Key, error) {
+	k := &Key{ID: id}
+	switch key := key.(type) {
+	case []byte:
+		if len(key) < 32 {
+			return nil, errors.New("jwt: HS256 secret shorter than 32 bytes")
+		}
+		k.Algorithm, k.secret = HS256, key
+	case *rsa.PrivateKey:
+		k.Algorithm, k.private, k.public = RS256, key, &key.PublicKey
+	case *rsa.PublicKey:
+		k.Algorithm, k.public = RS256, key
+	case *ecdsa.PrivateKey:
+		k.Algorithm, k.private, k.public = ES256, key, &key.PublicKey
+	case *ecdsa.PublicKey:
+		k.Algorithm, k.public = ES256, key
+	case ed25519.PrivateKey:
+		k.Algorithm, k.private, k.public = EdDSA, key, key.Public()
+	case ed25519.PublicKey:
+		k.Algorithm, k.public = EdDSA, key
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type %T", key)
+	}
+
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("jwt: RSA key shorter than %d bits", minRSABits)
+		}
+	case *ecdsa.PublicKey:
+		if public.Curve != elliptic.P256() {
+			return nil, errors.New("jwt: ES256 key not on the P-256 curve")
+		}
+	case ed25519.PublicKey:
+		if len(public) != ed25519.PublicKeySize {
+			return nil, errors.New("jwt: invalid Ed25519 key")
+		}
+	}
+	return k, nil
+}
+
+// canSign reports whether k has a secret or a private key.
+func (k *Key) canSign() bool {
+	return k.secret != nil || k.private != nil
+}
+
+// NewKeySet returns a key set holding keys. The last key that can sign is
+// the one Sign uses.
+func NewKeySet(keys ...*Key) *KeySet {
+	return &KeySet{keys: keys}
+}
+
+// Add adds key to the set, replacing any key with the same ID. If it can
+// sign, it becomes the key Sign uses.
+func (ks *KeySet) Add(key *Key) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = append(removeKey(ks.keys, key.ID), key)
+}
+
+// Remove removes the key with the given ID.
+func (ks *KeySet) Remove(id string) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = removeKey(ks.keys, id)
+}
+
+// Replace replaces every key of the set with keys, as when a JWKS is
+// loaded again.
+func (ks *KeySet) Replace(keys ...*Key) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = append([]*Key(nil), keys...)
+}
+
+// Key returns the key with the given ID, or nil.
+func (ks *KeySet) Key(id string) *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, k := range ks.keys {
+		if k.ID == id {
+			return k
+		}
+	}
+	return nil
+}
+
+// signingKey returns the last key that can sign, or nil.
+func (ks *KeySet) signingKey() *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for i := len(ks.keys) - 1; i >= 0; i-- {
+		if ks.keys[i].canSign() {
+			return ks.keys[i]
+		}
+	}
+	return nil
+}
+
+// candidates returns the keys that may have signed a token with the given
+// kid and alg: the key with that ID, or every key of the algorithm when the
+// token has no kid.
+func (ks *KeySet) candidates(kid, alg string) []*Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	var keys []*Key
+	for _, k := range ks.keys {
+		if k.Algorithm == alg && (kid == "" || k.ID == kid) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func removeKey(keys []*Key, id string) []*Key {
+	kept := make([]*Key, 0, len(keys))
+	for _, k := range keys {
+		if k.ID != id {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+// MarshalJSON writes the public keys of the set as a JWKS, RFC 7517, for
+// the services verifying its tokens. HS256 secrets are left out.
+func (ks *KeySet) MarshalJSON() ([]byte, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := jwks{Keys: []jwk{}}
+	for _, k := range ks.keys {
+		key := jwk{Kid: k.ID, Alg: k.Algorithm, Use: "sig"}
+		switch public := k.public.(type) {
+		case *rsa.PublicKey:
+			key.Kty = "RSA"
+			key.N = encodeSegment(public.N.Bytes())
+			key.E = encodeSegment(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			point, err := public.ECDH()
+			if err != nil {
+				return nil, err
+			}
+			// The uncompressed point is 0x04 || X || Y.
+			raw := point.Bytes()
+			key.Kty, key.Crv = "EC", "P-256"
+			key.X, key.Y = encodeSegment(raw[1:33]), encodeSegment(raw[33:])
+		case ed25519.PublicKey:
+			key.Kty, key.Crv = "OKP", "Ed25519"
+			key.X = encodeSegment(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, key)
+	}
+	return json.Marshal(set)
+}
+
+// Handler serves the public keys of the set as a JWKS, usually at
+// /.well-known/jwks.json:
+//
+//	e.GET("/.well-known/jwks.json", keys.Handler())
+func (ks *KeySet) Handler() func(*ron.CTX, context.Context) {
+	return func(c *ron.CTX, ctx context.Context) {
+		data, err := ks.MarshalJSON()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.W.Header().Set("Content-Type", "application/jwk-set+json")
+		c.W.Header().Set("Cache-Control", "public, max-age=300")
+		c.W.Write(data)
+	}
+}
+
+// ErrNoUsableKey is returned when a JWKS has keys but none that can verify
+// signatures.
+var ErrNoUsableKey = errors.New("jwt: no usable key in JWKS")
+
+// ReadJWKS reads the keys of a JWKS. Keys not used for signatures are left
+// out. Keys of unsupported types and keys NewKey rejects, such as RSA keys
+// shorter than 2048 bits, are skipped so one bad key doesn't keep the
+// others out: the usable keys are returned along with an error listing the
+// skipped ones, worth logging. When the JWKS has keys but none is usable,
+// the error wraps ErrNoUsableKey.
+func ReadJWKS(r io.Reader) ([]*Key, error) {
+	var set jwks
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return nil, fmt.Errorf("jwt: invalid JWKS: %w", err)
+	}
+
+	var keys []*Key
+	var skipped []error
+	for _, jk := range set.Keys {
+		if jk.Use != "" && jk.Use != "sig" {
+			continue
+		}
+		key, err := jk.key()
+		switch {
+		case err != nil:
+		case key == nil:
+			err = fmt.Errorf("unsupported key type %q", jk.Kty)
+		case jk.Alg != "" && jk.Alg != key.Algorithm:
+			err = fmt.Errorf("alg %s doesn't match the %s key", jk.Alg, key.Algorithm)
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("jwt: skipped key %q: %w", jk.Kid, err))
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 && len(set.Keys) > 0 {
+		return nil, errors.Join(append([]error{ErrNoUsableKey}, skipped...)...)
+	}
+	return keys, errors.Join(skipped...)
+}
+
+// LoadFile replaces the keys of the set with those of the JWKS file at
+// path. As with ReadJWKS, an error may list skipped keys while the others
+// are in use; only when no key is usable, or the file can't be read, is
+// the set left unchanged.
+func (ks *KeySet) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return ks.load(f)
+}
+
+// LoadURL replaces the keys of the set with those of the JWKS at url,
+// fetched with client or http.DefaultClient. Calling it again, for example
+// on a timer or after a token with an unknown kid, picks up rotated keys.
+// Errors are reported as by LoadFile.
+func (ks *KeySet) LoadURL(ctx context.Context, client *http.Client, url string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/jwk-set+json, application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwt: fetching JWKS from %s: %s", url, resp.Status)
+	}
+
+	return ks.load(io.LimitReader(resp.Body, 1<<20))
+}
+
+// load replaces the keys of the set with those of the JWKS read from r,
+// unless none of them is usable.
+func (ks *KeySet) load(r io.Reader) error {
+	keys, err := ReadJWKS(r)
+	if keys == nil && err != nil {
+		return err
+	}
+	ks.Replace(keys...)
+	return err
+}
+
+// key converts k to a Key, or returns nil for an unsupported type.
+func (k jwk) key() (*Key, error) {
+	switch {
+	case k.Kty == "oct":
+		secret, err := decodeSegment(k.K)
+		if err != nil {
+			return nil, err
+		}
+		return NewKey(k.Kid, secret)
+	case k.Kty == "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || exponent.Int64() < 3 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return NewKey(k.Kid, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())})
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeSegment(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 point")
+		}
+		point := bytes.Join([][]byte{{4}, x, y}, nil)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, err
+		}
+		return NewKey(k.Kid, &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		})
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, err
+		}
+		return NewKey(k.Kid, ed25519.PublicKey(x))
+	}
+	return nil, nil
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
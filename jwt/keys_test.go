@@ -0,0 +1,177 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ron"
+)
+
+func Test_NewKey(t *testing.T) {
+	smallRSA, _ := rsa.GenerateKey(rand.Reader, 1024)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+
+	tests := map[string]struct {
+		given         any
+		expectedError string
+	}{
+		"short secret":     {[]byte("short"), "jwt: HS256 secret shorter than 32 bytes"},
+		"small RSA key":    {smallRSA, "jwt: RSA key shorter than 2048 bits"},
+		"other curve":      {p384, "jwt: ES256 key not on the P-256 curve"},
+		"unsupported type": {"secret", "jwt: unsupported key type string"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewKey("k", tt.given)
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("Expected error: %s, Actual: %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func Test_KeySetRotation(t *testing.T) {
+	old, _ := NewKey("2023", []byte(strings.Repeat("o", 32)))
+	current, _ := NewKey("2024", []byte(strings.Repeat("c", 32)))
+	keys := NewKeySet(old)
+
+	oldToken, _ := keys.Sign(RegisteredClaims{Subject: "old"})
+	keys.Add(current)
+	newToken, _ := keys.Sign(RegisteredClaims{Subject: "new"})
+
+	var h header
+	decodeJSONSegment(strings.Split(newToken, ".")[0], &h)
+	if h.Kid != "2024" {
+		t.Errorf("Expected the added key to sign, Actual kid: %s", h.Kid)
+	}
+	for _, token := range []string{oldToken, newToken} {
+		if _, err := Verify[RegisteredClaims](token, VerifyConfig{Keys: keys}); err != nil {
+			t.Errorf("Expected both keys to verify during rotation, Actual: %v", err)
+		}
+	}
+
+	keys.Remove("2023")
+	if _, err := Verify[RegisteredClaims](oldToken, VerifyConfig{Keys: keys}); err != ErrUnknownKey {
+		t.Errorf("Expected error: %v, Actual: %v", ErrUnknownKey, err)
+	}
+}
+
+func Test_JWKS(t *testing.T) {
+	signing := NewKeySet(testKeys(t)...)
+
+	e := ron.New()
+	e.GET("/.well-known/jwks.json", signing.Handler())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/.well-known/jwks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set jwks
+	json.NewDecoder(resp.Body).Decode(&set)
+	resp.Body.Close()
+	if header := resp.Header.Get("Content-Type"); header != "application/jwk-set+json" {
+		t.Errorf("Expected Content-Type: application/jwk-set+json, Actual: %s", header)
+	}
+	var types []string
+	for _, k := range set.Keys {
+		types = append(types, k.Kty)
+		if k.K != "" {
+			t.Errorf("Expected no secrets in the JWKS, Actual: %+v", k)
+		}
+	}
+	if len(set.Keys) != 3 {
+		t.Errorf("Expected the 3 public keys, Actual: %v", types)
+	}
+
+	verifying := NewKeySet()
+	if err := verifying.LoadURL(context.Background(), nil, server.URL+"/.well-known/jwks.json"); err != nil {
+		t.Fatalf("LoadURL() failed: %v", err)
+	}
+	for _, key := range testKeys(t) {
+		token, _ := Sign(RegisteredClaims{Subject: key.ID}, key)
+		_, err := Verify[RegisteredClaims](token, VerifyConfig{Keys: verifying})
+		if key.Algorithm == HS256 {
+			if err != ErrUnknownKey {
+				t.Errorf("Expected HS256 to be unknown to the loaded set, Actual: %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Verify() with the loaded key failed: %v", key.Algorithm, err)
+		}
+	}
+	if _, err := verifying.Sign(RegisteredClaims{}); err == nil {
+		t.Error("Expected a set of public keys not to sign")
+	}
+
+	if err := verifying.LoadURL(context.Background(), nil, server.URL+"/missing"); err == nil {
+		t.Error("Expected an error for a missing JWKS")
+	}
+}
+
+func Test_LoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	jwksFile := `{"keys":[
+		{"kty":"oct","kid":"shared","alg":"HS256","k":"` + encodeSegment([]byte(strings.Repeat("s", 32))) + `"},
+		{"kty":"oct","kid":"enc","use":"enc","k":"` + encodeSegment([]byte(strings.Repeat("e", 32))) + `"},
+		{"kty":"EC","kid":"p384","crv":"P-384","x":"AA","y":"AA"}
+	]}`
+	if err := os.WriteFile(path, []byte(jwksFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := NewKeySet()
+	err := keys.LoadFile(path)
+	if err == nil || errors.Is(err, ErrNoUsableKey) || !strings.Contains(err.Error(), `"p384"`) {
+		t.Errorf("Expected the invalid key to be reported, Actual: %v", err)
+	}
+	if keys.Key("shared") == nil || keys.Key("enc") != nil || keys.Key("p384") != nil {
+		t.Errorf("Expected only the signature key, Actual: %+v", keys.keys)
+	}
+
+	token, _ := keys.Sign(RegisteredClaims{Subject: "1"})
+	if _, err := Verify[RegisteredClaims](token, VerifyConfig{Keys: keys}); err != nil {
+		t.Errorf("Verify() failed: %v", err)
+	}
+
+	smallRSA, _ := rsa.GenerateKey(rand.Reader, 1024)
+	os.WriteFile(path, []byte(`{"keys":[
+		{"kty":"EC","kid":"point","crv":"P-256","x":"AA","y":"AA"},
+		{"kty":"RSA","kid":"small","n":"`+encodeSegment(smallRSA.N.Bytes())+`","e":"AQAB"},
+		{"kty":"oct","kid":"shared","k":"`+encodeSegment([]byte(strings.Repeat("s", 32)))+`"}
+	]}`), 0o600)
+	err = keys.LoadFile(path)
+	for _, kid := range []string{`"point"`, `"small"`} {
+		if err == nil || !strings.Contains(err.Error(), kid) {
+			t.Errorf("Expected key %s to be reported, Actual: %v", kid, err)
+		}
+	}
+	if keys.Key("shared") == nil || keys.Key("point") != nil || keys.Key("small") != nil {
+		t.Errorf("Expected only the valid key, Actual: %+v", keys.keys)
+	}
+
+	os.WriteFile(path, []byte(`{"keys":[
+		{"kty":"RSA","kid":"small","n":"`+encodeSegment(smallRSA.N.Bytes())+`","e":"AQAB"},
+		{"kty":"oct","kid":"enc","use":"enc","k":"`+encodeSegment([]byte(strings.Repeat("e", 32)))+`"}
+	]}`), 0o600)
+	if err := keys.LoadFile(path); !errors.Is(err, ErrNoUsableKey) || !strings.Contains(err.Error(), `"small"`) {
+		t.Errorf("Expected: %v, Actual: %v", ErrNoUsableKey, err)
+	}
+	if keys.Key("shared") == nil {
+		t.Error("Expected the keys to be kept when none is usable")
+	}
+}
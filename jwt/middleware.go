@@ -0,0 +1,36 @@
+package jwt
+
+import (
+	"context"
+
+	"ron"
+)
+
+// Middleware requires a bearer token that Verify accepts with config, and
+// stores its claims, as a C, as the principal of the request. Handlers read
+// them with FromContext:
+//
+//	api := e.GROUP("/api")
+//	api.USE(jwt.Middleware[Claims](e, jwt.VerifyConfig{Keys: keys, Audience: "api"}))
+//
+//	api.GET("/me", func(c *ron.CTX, ctx context.Context) {
+//		claims, _ := jwt.FromContext[Claims](ctx)
+//		c.JSON(http.StatusOK, claims)
+//	})
+//
+// Other requests are rejected with 401 through the engine's error
+// rendering, as by ron.BearerAuthMiddleware.
+func Middleware[C Claims](e *ron.Engine, config VerifyConfig) ron.Middleware {
+	return e.BearerAuthMiddleware(ron.BearerAuthConfig{
+		Validate: func(ctx context.Context, token string) (any, error) {
+			return Verify[C](token, config)
+		},
+	})
+}
+
+// FromContext returns the claims stored by Middleware and whether there
+// are claims of type C.
+func FromContext[C Claims](ctx context.Context) (C, bool) {
+	claims, ok := ron.PrincipalFromContext(ctx).(C)
+	return claims, ok
+}
@@ -0,0 +1,68 @@
+package jwt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ron"
+)
+
+func Test_Middleware(t *testing.T) {
+	key, _ := NewKey("k1", []byte(strings.Repeat("k", 32)))
+	keys := NewKeySet(key)
+	valid, _ := keys.Sign(testClaims{
+		RegisteredClaims: RegisteredClaims{Subject: "42", ExpiresAt: NewNumericDate(time.Now().Add(time.Hour))},
+		Role:             "admin",
+	})
+	expired, _ := keys.Sign(testClaims{
+		RegisteredClaims: RegisteredClaims{Subject: "42", ExpiresAt: NewNumericDate(time.Now().Add(-time.Hour))},
+	})
+
+	tests := map[string]struct {
+		givenAuthorization string
+		expectedCode       int
+		expectedBody       string
+	}{
+		"valid token":   {"Bearer " + valid, http.StatusOK, "42 admin"},
+		"expired token": {"Bearer " + expired, http.StatusUnauthorized, "Unauthorized\n"},
+		"no token":      {"", http.StatusUnauthorized, "Unauthorized\n"},
+		"garbage":       {"Bearer abc.def", http.StatusUnauthorized, "Unauthorized\n"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := ron.New()
+			e.USE(Middleware[testClaims](e, VerifyConfig{Keys: keys, RequireExpiry: true}))
+			e.GET("/me", func(c *ron.CTX, ctx context.Context) {
+				claims, ok := FromContext[testClaims](ctx)
+				fromCTX, _ := ron.Value[testClaims](c, ron.Principal)
+				if !ok || fromCTX.Subject != claims.Subject {
+					c.Error(&ron.HTTPError{Code: http.StatusInternalServerError})
+					return
+				}
+				c.W.Write([]byte(claims.Subject + " " + claims.Role))
+			})
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/me", nil)
+			if tt.givenAuthorization != "" {
+				req.Header.Set("Authorization", tt.givenAuthorization)
+			}
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, rr.Code)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body: %q, Actual: %q", tt.expectedBody, rr.Body.String())
+			}
+			if tt.expectedCode == http.StatusUnauthorized && !strings.HasPrefix(rr.Header().Get("WWW-Authenticate"), "Bearer") {
+				t.Errorf("Expected a Bearer challenge, Actual: %q", rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -12,6 +12,11 @@ import (
 func (e *Engine) TimeOutMiddleware() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if e.isStreamingRoute(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			ctx, cancel := context.WithTimeout(r.Context(), e.Config.Timeout)
 			defer cancel()
 
@@ -44,6 +49,9 @@ func (e *Engine) RequestIdMiddleware() Middleware {
 				id = fmt.Sprintf("%d", time.Now().UnixNano())
 			}
 			ctx = context.WithValue(ctx, RequestID, id)
+			if c := FromContext(ctx); c != nil {
+				c.Set(RequestID, id)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
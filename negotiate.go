@@ -0,0 +1,83 @@
+package ron
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// negotiableTypes are the formats Negotiate can write, in order of
+// preference.
+var negotiableTypes = []string{HeaderJSON, HeaderXML, "text/xml"}
+
+// Negotiate writes data in the format the Accept header prefers among JSON
+// and XML. JSON is used when the client accepts either equally or sends no
+// Accept header. A client accepting neither gets 406 Not Acceptable through
+// CTX.Error.
+func (c *CTX) Negotiate(code int, data any) {
+	switch negotiateType(c.R.Header.Get("Accept"), negotiableTypes) {
+	case HeaderJSON:
+		c.JSON(code, data)
+	case HeaderXML, "text/xml":
+		c.XML(code, data)
+	default:
+		c.Error(&HTTPError{Code: http.StatusNotAcceptable})
+	}
+}
+
+// negotiateType returns the offer with the highest quality in accept, or
+// the first offer when accept is empty. Each offer takes the quality of the
+// most specific media range matching it, and ties go to the earlier offer.
+// It returns "" when no offer is acceptable.
+func negotiateType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		typ, subtype string
+		q            float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			var s int
+			switch {
+			case r.typ == typ && r.subtype == subtype:
+				s = 2
+			case r.typ == typ && r.subtype == "*":
+				s = 1
+			case r.typ == "*" && r.subtype == "*":
+				s = 0
+			default:
+				continue
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
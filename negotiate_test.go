@@ -0,0 +1,83 @@
+package ron
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_negotiateType(t *testing.T) {
+	tests := map[string]struct {
+		accept   string
+		expected string
+	}{
+		"no accept":            {"", HeaderJSON},
+		"any":                  {"*/*", HeaderJSON},
+		"json":                 {"application/json", HeaderJSON},
+		"xml":                  {"application/xml", HeaderXML},
+		"text xml":             {"text/xml", "text/xml"},
+		"quality":              {"application/json;q=0.5, application/xml", HeaderXML},
+		"specific beats range": {"application/*;q=0.9, application/json;q=0.1", HeaderXML},
+		"browser":              {"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", HeaderXML},
+		"excluded":             {"application/json;q=0, */*", HeaderXML},
+		"none acceptable":      {"text/html", ""},
+		"malformed quality":    {"application/xml;q=high, application/json", HeaderJSON},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := negotiateType(tt.accept, negotiableTypes); actual != tt.expected {
+				t.Errorf("Expected: %q, Actual: %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_Negotiate(t *testing.T) {
+	tests := map[string]struct {
+		accept       string
+		expectedCode int
+		expectedType string
+		expectedBody string
+	}{
+		"json": {
+			accept:       "application/json",
+			expectedCode: http.StatusCreated,
+			expectedType: HeaderJSON,
+			expectedBody: `{"name":"Foo","email":"foo@example.com"}` + "\n",
+		},
+		"xml": {
+			accept:       "application/xml",
+			expectedCode: http.StatusCreated,
+			expectedType: HeaderXML,
+			expectedBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<Contact email="foo@example.com"><name>Foo</name></Contact>`,
+		},
+		"not acceptable": {
+			accept:       "text/html",
+			expectedCode: http.StatusNotAcceptable,
+			expectedType: HeaderPlain_UTF8,
+			expectedBody: http.StatusText(http.StatusNotAcceptable) + "\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			c := &CTX{W: newResponseWriter(rr), R: req}
+
+			c.Negotiate(http.StatusCreated, Contact{Name: "Foo", Email: "foo@example.com"})
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, rr.Code)
+			}
+			if header := rr.Header().Get("Content-Type"); header != tt.expectedType {
+				t.Errorf("Expected Content-Type: %s, Actual: %s", tt.expectedType, header)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body: %q, Actual: %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
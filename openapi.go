@@ -0,0 +1,578 @@
+package ron
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIConfig describes the API in the document built by OpenAPI.
+type OpenAPIConfig struct {
+	Title       string
+	Version     string
+	Description string
+	// Servers are the base URLs of the API.
+	Servers []string
+	// Path is where ServeOpenAPI serves the document. It defaults to
+	// /openapi.json.
+	Path string
+}
+
+type (
+	openAPIDocument struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       openAPIInfo                             `json:"info"`
+		Servers    []openAPIServer                         `json:"servers,omitempty"`
+		Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+		Components *openAPIComponents                      `json:"components,omitempty"`
+	}
+
+	openAPIInfo struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+
+	openAPIServer struct {
+		URL string `json:"url"`
+	}
+
+	openAPIOperation struct {
+		Summary     string                     `json:"summary,omitempty"`
+		Description string                     `json:"description,omitempty"`
+		OperationID string                     `json:"operationId,omitempty"`
+		Tags        []string                   `json:"tags,omitempty"`
+		Deprecated  bool                       `json:"deprecated,omitempty"`
+		Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+		RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+		Responses   map[string]openAPIResponse `json:"responses"`
+	}
+
+	openAPIParameter struct {
+		Name     string      `json:"name"`
+		In       string      `json:"in"`
+		Required bool        `json:"required,omitempty"`
+		Schema   *jsonSchema `json:"schema"`
+	}
+
+	openAPIRequestBody struct {
+		Required bool                        `json:"required,omitempty"`
+		Content  map[string]openAPIMediaType `json:"content"`
+	}
+
+	openAPIMediaType struct {
+		Schema *jsonSchema `json:"schema"`
+	}
+
+	openAPIResponse struct {
+		Description string                      `json:"description"`
+		Content     map[string]openAPIMediaType `json:"content,omitempty"`
+	}
+
+	openAPIComponents struct {
+		Schemas map[string]*jsonSchema `json:"schemas"`
+	}
+
+	// jsonSchema is the subset of JSON Schema 2020-12 used in the document.
+	jsonSchema struct {
+		Ref                  string                 `json:"$ref,omitempty"`
+		Type                 string                 `json:"type,omitempty"`
+		Format               string                 `json:"format,omitempty"`
+		Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+		Required             []string               `json:"required,omitempty"`
+		Items                *jsonSchema            `json:"items,omitempty"`
+		AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+		Enum                 []any                  `json:"enum,omitempty"`
+		Default              any                    `json:"default,omitempty"`
+		Pattern              string                 `json:"pattern,omitempty"`
+		Minimum              *float64               `json:"minimum,omitempty"`
+		Maximum              *float64               `json:"maximum,omitempty"`
+		ExclusiveMinimum     *float64               `json:"exclusiveMinimum,omitempty"`
+		ExclusiveMaximum     *float64               `json:"exclusiveMaximum,omitempty"`
+		MinLength            *int                   `json:"minLength,omitempty"`
+		MaxLength            *int                   `json:"maxLength,omitempty"`
+		MinItems             *int                   `json:"minItems,omitempty"`
+		MaxItems             *int                   `json:"maxItems,omitempty"`
+	}
+
+	// schemaBuilder builds schemas for one document, collecting named JSON
+	// structs as components.
+	schemaBuilder struct {
+		components map[string]*jsonSchema
+		// names holds the component name given to each type.
+		names map[reflect.Type]string
+	}
+)
+
+// parameterSources are the binding tags documented as parameters, with the
+// location OpenAPI gives them.
+var parameterSources = []struct{ tag, in string }{
+	{pathBinding.tag, "path"},
+	{queryBinding.tag, "query"},
+	{headerBinding.tag, "header"},
+	{cookieBinding.tag, "cookie"},
+}
+
+// OpenAPI builds an OpenAPI 3.1 document, as JSON, from the registered
+// routes and their documentation.
+func (e *Engine) OpenAPI(config OpenAPIConfig) ([]byte, error) {
+	doc := openAPIDocument{
+		OpenAPI: "3.1.0",
+		Info: openAPIInfo{
+			Title:       config.Title,
+			Version:     config.Version,
+			Description: config.Description,
+		},
+		Paths: make(map[string]map[string]*openAPIOperation),
+	}
+	for _, server := range config.Servers {
+		doc.Servers = append(doc.Servers, openAPIServer{URL: server})
+	}
+
+	sb := &schemaBuilder{components: make(map[string]*jsonSchema), names: make(map[reflect.Type]string)}
+	for _, route := range e.routes {
+		if route.Hidden {
+			continue
+		}
+		path := openAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = sb.operation(route)
+	}
+	if len(sb.components) > 0 {
+		doc.Components = &openAPIComponents{Schemas: sb.components}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// ServeOpenAPI serves the document built by OpenAPI at config.Path. It is
+// built on every request, so routes registered later are included.
+func (e *Engine) ServeOpenAPI(config OpenAPIConfig) {
+	path := defaultIfEmpty("/openapi.json", config.Path)
+	e.GET(path, func(c *CTX, ctx context.Context) {
+		doc, err := e.OpenAPI(config)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.W.Header().Set("Content-Type", HeaderJSON)
+		c.W.Write(doc)
+	}, func(r *Route) {
+		r.Hidden = true
+	})
+}
+
+// openAPIPath turns a ServeMux pattern into an OpenAPI path: {name...}
+// becomes {name} and {$} is dropped.
+func openAPIPath(pattern string) string {
+	pattern = strings.TrimSuffix(pattern, "{$}")
+	return strings.ReplaceAll(pattern, "...}", "}")
+}
+
+func (sb *schemaBuilder) operation(route *Route) *openAPIOperation {
+	op := &openAPIOperation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		OperationID: route.OperationID,
+		Tags:        route.Tags,
+		Deprecated:  route.Deprecated,
+		Responses:   make(map[string]openAPIResponse),
+	}
+
+	if t := reflect.TypeOf(route.Request); t != nil {
+		t = derefType(t)
+		if t.Kind() == reflect.Struct {
+			op.Parameters = sb.parameters(t)
+			if route.Method != http.MethodGet {
+				op.RequestBody = sb.requestBody(t)
+			}
+		}
+	}
+	op.Parameters = addPathParameters(op.Parameters, openAPIPath(route.Path))
+
+	codes := make([]int, 0, len(route.Responses))
+	for code := range route.Responses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		response := openAPIResponse{Description: http.StatusText(code)}
+		if t := reflect.TypeOf(route.Responses[code]); t != nil {
+			response.Content = map[string]openAPIMediaType{
+				HeaderJSON: {Schema: sb.schema(t, "json")},
+			}
+		}
+		op.Responses[strconv.Itoa(code)] = response
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["200"] = openAPIResponse{Description: http.StatusText(http.StatusOK)}
+	}
+	return op
+}
+
+// parameters documents the fields of t bound from the path, query, headers
+// and cookies.
+func (sb *schemaBuilder) parameters(t reflect.Type) []openAPIParameter {
+	var params []openAPIParameter
+	for _, sf := range structFields(t) {
+		for _, source := range parameterSources {
+			name, _, _ := strings.Cut(sf.Tag.Get(source.tag), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			schema := sb.schema(sf.Type, source.tag)
+			required := applyRules(schema, sf)
+			params = append(params, openAPIParameter{
+				Name:     name,
+				In:       source.in,
+				Required: required || source.in == "path",
+				Schema:   schema,
+			})
+		}
+	}
+	return params
+}
+
+// addPathParameters declares the wildcards of path that no field of the
+// request documents, as OpenAPI requires every one of them. They are
+// required strings.
+func addPathParameters(params []openAPIParameter, path string) []openAPIParameter {
+	declared := make(map[string]bool)
+	for _, p := range params {
+		if p.In == "path" {
+			declared[p.Name] = true
+		}
+	}
+	for _, segment := range strings.Split(path, "/") {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(name, "}")
+		if name == "" || declared[name] {
+			continue
+		}
+		declared[name] = true
+		params = append(params, openAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &jsonSchema{Type: "string"},
+		})
+	}
+	return params
+}
+
+// requestBody documents the JSON and form fields of t. Fields tagged only
+// as parameters are left out of the JSON body, and only fields with a form
+// tag make up the form body.
+func (sb *schemaBuilder) requestBody(t reflect.Type) *openAPIRequestBody {
+	content := make(map[string]openAPIMediaType)
+
+	if schema := sb.objectSchema(t, "json", isJSONBodyField); len(schema.Properties) > 0 {
+		content[HeaderJSON] = openAPIMediaType{Schema: schema}
+	}
+
+	hasForm := func(sf reflect.StructField) bool {
+		name := sf.Tag.Get(formBinding.tag)
+		return name != "" && name != "-"
+	}
+	if schema := sb.objectSchema(t, "form", hasForm); len(schema.Properties) > 0 {
+		mediaType := "application/x-www-form-urlencoded"
+		for _, sf := range structFields(t) {
+			if hasForm(sf) && isFileType(sf.Type) {
+				mediaType = "multipart/form-data"
+			}
+		}
+		content[mediaType] = openAPIMediaType{Schema: schema}
+	}
+
+	if len(content) == 0 {
+		return nil
+	}
+	return &openAPIRequestBody{Required: true, Content: content}
+}
+
+func isJSONBodyField(sf reflect.StructField) bool {
+	if tag := sf.Tag.Get("json"); tag != "" {
+		return tag != "-"
+	}
+	for _, tag := range []string{pathBinding.tag, queryBinding.tag, headerBinding.tag, cookieBinding.tag, formBinding.tag} {
+		if sf.Tag.Get(tag) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// schema returns the schema of t as bound from a source using tag, such as
+// json or query. Named structs in JSON become components.
+func (sb *schemaBuilder) schema(t reflect.Type, tag string) *jsonSchema {
+	switch {
+	case t == timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case t == durationType && tag != "json":
+		return &jsonSchema{Type: "string", Format: "duration"}
+	case t == fileHeaderType:
+		return &jsonSchema{Type: "string", Format: "binary"}
+	case t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return &jsonSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return sb.schema(t.Elem(), tag)
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &jsonSchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &jsonSchema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+		return &jsonSchema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &jsonSchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &jsonSchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && tag == "json" {
+			return &jsonSchema{Type: "string", Format: "byte"}
+		}
+		return &jsonSchema{Type: "array", Items: sb.schema(t.Elem(), tag)}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: sb.schema(t.Elem(), tag)}
+	case reflect.Struct:
+		if tag != "json" || t.Name() == "" {
+			return sb.objectSchema(t, tag, nil)
+		}
+		name, ok := sb.names[t]
+		if !ok {
+			name = sb.componentName(t)
+			sb.names[t] = name
+			sb.components[name] = &jsonSchema{}
+			*sb.components[name] = *sb.objectSchema(t, tag, nil)
+		}
+		return &jsonSchema{Ref: "#/components/schemas/" + name}
+	}
+	return &jsonSchema{}
+}
+
+// componentName picks a free component name for t. A type whose name is
+// taken by another one, from another package or another instantiation of a
+// generic type, gets its package in front, then a number.
+func (sb *schemaBuilder) componentName(t reflect.Type) string {
+	name := componentKey(t.Name())
+	if _, taken := sb.components[name]; !taken {
+		return name
+	}
+	name = componentKey(path.Base(t.PkgPath()) + "." + t.Name())
+	candidate := name
+	for i := 2; ; i++ {
+		if _, taken := sb.components[candidate]; !taken {
+			return candidate
+		}
+		candidate = name + strconv.Itoa(i)
+	}
+}
+
+// componentKey replaces the characters a component name can't hold, such
+// as the brackets and slashes of generic type names, with underscores.
+func componentKey(name string) string {
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	return strings.TrimRight(key, "_")
+}
+
+// objectSchema builds the schema of the fields of t accepted by include,
+// or of every field when include is nil.
+func (sb *schemaBuilder) objectSchema(t reflect.Type, tag string, include func(reflect.StructField) bool) *jsonSchema {
+	schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+	for _, sf := range structFields(t) {
+		if include != nil && !include(sf) {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		property := sb.schema(sf.Type, tag)
+		if property.Ref != "" {
+			schema.Properties[name] = property
+			if hasRequiredRule(sf) {
+				schema.Required = append(schema.Required, name)
+			}
+			continue
+		}
+		if applyRules(property, sf) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// structFields lists the exported fields of t, promoting those of embedded
+// structs.
+func structFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && sf.Tag.Get("json") == "" {
+			fields = append(fields, structFields(sf.Type)...)
+			continue
+		}
+		fields = append(fields, sf)
+	}
+	return fields
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func hasRequiredRule(sf reflect.StructField) bool {
+	rules, err := parseRules(sf.Tag.Get("validate"))
+	return err == nil && hasRule(rules, "required")
+}
+
+// applyRules adds the validation rules and the default of sf to schema and
+// reports whether the field is required.
+func applyRules(schema *jsonSchema, sf reflect.StructField) bool {
+	t := derefType(sf.Type)
+	kind := t.Kind()
+	numeric := isNumericKind(kind) && t != durationType
+
+	if def, ok := sf.Tag.Lookup("default"); ok {
+		schema.Default = defaultValue(def, schema.Type)
+	}
+
+	rules, err := parseRules(sf.Tag.Get("validate"))
+	if err != nil {
+		return false
+	}
+
+	required := false
+	for _, rule := range rules {
+		param, err := strconv.ParseFloat(rule.param, 64)
+		hasParam := err == nil
+		switch rule.name {
+		case "required":
+			required = true
+		case "min", "max", "len":
+			if !hasParam {
+				continue
+			}
+			n := int(param)
+			switch {
+			case kind == reflect.String:
+				setBounds(rule.name, &schema.MinLength, &schema.MaxLength, n)
+			case kind == reflect.Slice || kind == reflect.Array:
+				setBounds(rule.name, &schema.MinItems, &schema.MaxItems, n)
+			case numeric:
+				if rule.name != "max" {
+					schema.Minimum = &param
+				}
+				if rule.name != "min" {
+					schema.Maximum = &param
+				}
+			}
+		case "gt", "gte", "lt", "lte":
+			if !numeric || !hasParam {
+				continue
+			}
+			switch rule.name {
+			case "gt":
+				schema.ExclusiveMinimum = &param
+			case "gte":
+				schema.Minimum = &param
+			case "lt":
+				schema.ExclusiveMaximum = &param
+			case "lte":
+				schema.Maximum = &param
+			}
+		case "oneof":
+			target := schema
+			if schema.Items != nil {
+				target = schema.Items
+			}
+			for _, option := range strings.Fields(rule.param) {
+				target.Enum = append(target.Enum, defaultValue(option, target.Type))
+			}
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid":
+			schema.Format = "uuid"
+		case "regexp":
+			schema.Pattern = rule.param
+		}
+	}
+	return required
+}
+
+func setBounds(rule string, lower, upper **int, n int) {
+	if rule != "max" {
+		*lower = &n
+	}
+	if rule != "min" {
+		*upper = &n
+	}
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// defaultValue converts a value written in a tag to the JSON type of the
+// schema, keeping it as a string when it doesn't parse.
+func defaultValue(value, schemaType string) any {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "array":
+		return strings.Split(value, ",")
+	}
+	return value
+}
@@ -0,0 +1,188 @@
+package ron
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+type apiAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city" validate:"required"`
+}
+
+type apiUser struct {
+	ID        string      `json:"id" validate:"uuid"`
+	Name      string      `json:"name"`
+	Email     string      `json:"email"`
+	Roles     []string    `json:"roles"`
+	Address   *apiAddress `json:"address,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type listUsers struct {
+	Page  int      `query:"page" default:"1" validate:"gte=1"`
+	Limit int      `query:"limit" default:"20" validate:"min=1,max=100"`
+	Sort  string   `query:"sort" validate:"omitempty,oneof=name -name created"`
+	Roles []string `query:"role"`
+}
+
+type getUser struct {
+	ID        string `path:"id" validate:"uuid"`
+	RequestID string `header:"X-Request-Id"`
+	Session   string `cookie:"session"`
+}
+
+type createUserRequest struct {
+	Team    string            `path:"team"`
+	DryRun  bool              `query:"dry_run"`
+	Name    string            `json:"name" validate:"required,min=2,max=50"`
+	Email   string            `json:"email" validate:"required,email"`
+	Age     int               `json:"age" validate:"gte=18,lt=130"`
+	Tags    []string          `json:"tags" validate:"max=5"`
+	Address apiAddress        `json:"address" validate:"required"`
+	Meta    map[string]string `json:"meta"`
+}
+
+type apiPage[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+}
+
+type uploadAvatar struct {
+	Caption string                `form:"caption" validate:"max=140"`
+	File    *multipart.FileHeader `form:"file" validate:"required"`
+}
+
+func newOpenAPIEngine() *Engine {
+	e := New()
+	noop := func(c *CTX, ctx context.Context) {}
+
+	api := e.GROUP("/api")
+	api.GET("/users", noop, func(r *Route) {
+		r.Summary = "List users"
+		r.Tags = []string{"users"}
+		r.Request = listUsers{}
+		r.Responses = map[int]any{http.StatusOK: []apiUser{}}
+	})
+	api.GET("/users/{id}", noop, func(r *Route) {
+		r.Summary = "Get a user"
+		r.Tags = []string{"users"}
+		r.OperationID = "getUser"
+		r.Request = getUser{}
+		r.Responses = map[int]any{http.StatusOK: apiUser{}, http.StatusNotFound: nil}
+	})
+	api.POST("/teams/{team}/users", Typed(func(ctx context.Context, req createUserRequest) (apiUser, error) {
+		return apiUser{}, nil
+	}), func(r *Route) {
+		r.Summary = "Create a user"
+		r.Description = "Creates a user in a team."
+		r.Tags = []string{"users"}
+		r.Request = createUserRequest{}
+		r.Responses = map[int]any{http.StatusCreated: apiUser{}, http.StatusUnprocessableEntity: nil}
+	})
+	api.POST("/users/{id}/avatar", noop, func(r *Route) {
+		r.Request = &uploadAvatar{}
+		r.Responses = map[int]any{http.StatusNoContent: nil}
+		r.Deprecated = true
+	})
+	api.GET("/teams/{team}/addresses", noop, func(r *Route) {
+		// Another type named apiAddress, to check the component names.
+		type apiAddress struct {
+			Country string `json:"country"`
+		}
+		r.Responses = map[int]any{http.StatusOK: []apiAddress{}}
+	})
+	api.GET("/teams/{team}/users", noop, func(r *Route) {
+		r.Responses = map[int]any{http.StatusOK: apiPage[apiUser]{}, http.StatusPartialContent: apiPage[string]{}}
+	})
+	e.GET("/files/{path...}", noop)
+	e.GET("/{$}", noop)
+	e.ServeOpenAPI(OpenAPIConfig{Path: "/docs/openapi.json"})
+	return e
+}
+
+var openAPIConfig = OpenAPIConfig{
+	Title:       "Users API",
+	Version:     "1.0.0",
+	Description: "Manages users.",
+	Servers:     []string{"https://api.example.com"},
+}
+
+func Test_OpenAPI(t *testing.T) {
+	e := newOpenAPIEngine()
+	actual, err := e.OpenAPI(openAPIConfig)
+	if err != nil {
+		t.Fatalf("OpenAPI() failed: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "openapi.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, append(actual, '\n'), 0o644); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(expected), actual) {
+		t.Errorf("OpenAPI document differs from %s; run go test -run Test_OpenAPI -update to refresh it\n%s", golden, actual)
+	}
+}
+
+func Test_ServeOpenAPI(t *testing.T) {
+	e := newOpenAPIEngine()
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/docs/openapi.json", nil)
+	e.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code: %d, Actual: %d", http.StatusOK, rr.Code)
+	}
+	if header := rr.Header().Get("Content-Type"); header != HeaderJSON {
+		t.Errorf("Expected Content-Type: %s, Actual: %s", HeaderJSON, header)
+	}
+	expected, _ := e.OpenAPI(OpenAPIConfig{})
+	if !bytes.Equal(rr.Body.Bytes(), expected) {
+		t.Errorf("Expected served document to match OpenAPI(), Actual: %s", rr.Body.String())
+	}
+}
+
+func Test_Routes(t *testing.T) {
+	e := newOpenAPIEngine()
+	routes := e.Routes()
+
+	expected := []struct{ method, path string }{
+		{"GET", "/api/users"},
+		{"GET", "/api/users/{id}"},
+		{"POST", "/api/teams/{team}/users"},
+		{"POST", "/api/users/{id}/avatar"},
+		{"GET", "/api/teams/{team}/addresses"},
+		{"GET", "/api/teams/{team}/users"},
+		{"GET", "/files/{path...}"},
+		{"GET", "/{$}"},
+		{"GET", "/docs/openapi.json"},
+	}
+	if len(routes) != len(expected) {
+		t.Fatalf("Expected %d routes, Actual: %d", len(expected), len(routes))
+	}
+	for i, route := range routes {
+		if route.Method != expected[i].method || route.Path != expected[i].path {
+			t.Errorf("Expected route: %s %s, Actual: %s %s", expected[i].method, expected[i].path, route.Method, route.Path)
+		}
+	}
+	if !routes[len(routes)-1].Hidden {
+		t.Error("Expected the document route to be hidden")
+	}
+}
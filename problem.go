@@ -0,0 +1,194 @@
+package ron
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type (
+	// Problem is an RFC 9457 problem details object. It is an error, so a
+	// handler can pass one to CTX.Error to choose exactly what the client
+	// sees:
+	//
+	//	c.Error(&ron.Problem{
+	//		Type:   "https://example.com/probs/out-of-credit",
+	//		Title:  "You do not have enough credit.",
+	//		Status: http.StatusForbidden,
+	//		Detail: "Your current balance is 30, but that costs 50.",
+	//		Extensions: map[string]any{"balance": 30},
+	//	})
+	Problem struct {
+		// Type is a URI identifying the kind of problem. It defaults to
+		// about:blank.
+		Type string
+		// Title is a short summary of the kind of problem. It defaults to
+		// the status text.
+		Title string
+		// Status is the HTTP status code. It defaults to 500.
+		Status int
+		// Detail explains this occurrence of the problem.
+		Detail string
+		// Instance is a URI identifying this occurrence of the problem.
+		Instance string
+		// Extensions are extra members written next to the standard ones.
+		// Members named like a standard one are ignored.
+		Extensions map[string]any
+	}
+
+	// ProblemField describes one field in the errors extension of a Problem
+	// built from BindingErrors, ValidationErrors or a ParamError.
+	ProblemField struct {
+		// Field is the request key, such as items[0].qty.
+		Field string `json:"field"`
+		// Source is where the value came from, such as query or json. It
+		// is empty for validation errors.
+		Source string `json:"source,omitempty"`
+		// Rule is the validation rule that failed, if any.
+		Rule    string `json:"rule,omitempty"`
+		Message string `json:"message"`
+	}
+)
+
+// problemMembers are the members defined by RFC 9457, which extensions
+// can't replace.
+var problemMembers = map[string]bool{"type": true, "title": true, "status": true, "detail": true, "instance": true}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.title()
+}
+
+func (p *Problem) StatusCode() int {
+	if p.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return p.Status
+}
+
+func (p *Problem) title() string {
+	if p.Title != "" {
+		return p.Title
+	}
+	return http.StatusText(p.StatusCode())
+}
+
+// MarshalJSON writes the standard members, with their defaults, followed by
+// the extensions.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for name, v := range p.Extensions {
+		if !problemMembers[name] {
+			members[name] = v
+		}
+	}
+	members["type"] = defaultIfEmpty("about:blank", p.Type)
+	members["title"] = p.title()
+	members["status"] = p.StatusCode()
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON reads a problem, keeping unknown members as extensions.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	*p = Problem{}
+	fields := map[string]any{
+		"type":     &p.Type,
+		"title":    &p.Title,
+		"status":   &p.Status,
+		"detail":   &p.Detail,
+		"instance": &p.Instance,
+	}
+	for name, raw := range members {
+		if field, ok := fields[name]; ok {
+			if err := json.Unmarshal(raw, field); err != nil {
+				return err
+			}
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]any)
+		}
+		p.Extensions[name] = v
+	}
+	return nil
+}
+
+// NewProblem describes err as a Problem. A Problem in the chain is returned
+// as it is. Otherwise the status comes from StatusCode and the detail from
+// the error message, except for server errors, whose message could leak
+// internals. BindingErrors, ValidationErrors and ParamError also list the
+// rejected fields in an errors extension of ProblemFields.
+func NewProblem(err error) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	p := &Problem{Status: StatusCode(err)}
+	if p.Status < http.StatusInternalServerError {
+		p.Detail = err.Error()
+	}
+
+	var fields []ProblemField
+	var bindingErrs BindingErrors
+	var validationErrs ValidationErrors
+	var paramErr *ParamError
+	switch {
+	case errors.As(err, &bindingErrs):
+		for _, be := range bindingErrs {
+			fields = append(fields, ProblemField{Field: be.Key, Source: be.Source, Message: be.Reason})
+		}
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			fields = append(fields, ProblemField{Field: fe.Key, Rule: fe.Rule, Message: fe.Message()})
+		}
+	case errors.As(err, &paramErr):
+		fields = append(fields, ProblemField{Field: paramErr.Key, Source: paramErr.Source, Message: paramErr.Err.Error()})
+	}
+	if fields != nil {
+		p.Detail = "The request has invalid fields."
+		p.Extensions = map[string]any{"errors": fields}
+	}
+	return p
+}
+
+// Problem writes p as application/problem+json with its status code.
+func (c *CTX) Problem(p *Problem) {
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(p); err != nil {
+		http.Error(c.W, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.W.Header().Set("Content-Type", HeaderProblemJSON)
+	c.W.WriteHeader(p.StatusCode())
+	buf.WriteTo(c.W)
+}
+
+// wantsProblem reports whether the client prefers JSON to plain text, as
+// API clients sending Accept: application/json do. Browsers and clients
+// without an Accept header get plain text.
+func wantsProblem(r *http.Request) bool {
+	switch negotiateType(r.Header.Get("Accept"), []string{"text/plain", HeaderProblemJSON, HeaderJSON}) {
+	case HeaderProblemJSON, HeaderJSON:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package ron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"ron/testhelpers"
+	"strings"
+	"testing"
+)
+
+func Test_ProblemJSON(t *testing.T) {
+	tests := map[string]struct {
+		given    *Problem
+		expected string
+	}{
+		"defaults": {
+			given:    &Problem{},
+			expected: `{"status":500,"title":"Internal Server Error","type":"about:blank"}`,
+		},
+		"all members": {
+			given: &Problem{
+				Type:     "https://example.com/probs/out-of-credit",
+				Title:    "You do not have enough credit.",
+				Status:   http.StatusForbidden,
+				Detail:   "Your current balance is 30, but that costs 50.",
+				Instance: "/account/12345/msgs/abc",
+				Extensions: map[string]any{
+					"balance": 30,
+					"status":  200,
+				},
+			},
+			expected: `{"balance":30,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc","status":403,"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := json.Marshal(tt.given)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected: %s, Actual: %s", tt.expected, data)
+			}
+
+			var p Problem
+			if err := json.Unmarshal(data, &p); err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			if p.StatusCode() != tt.given.StatusCode() || p.Detail != tt.given.Detail {
+				t.Errorf("Expected round trip of %+v, Actual: %+v", tt.given, p)
+			}
+		})
+	}
+}
+
+func Test_NewProblem(t *testing.T) {
+	problem := &Problem{Status: http.StatusConflict, Title: "Taken"}
+
+	tests := map[string]struct {
+		givenErr         error
+		expectedStatus   int
+		expectedDetail   string
+		expectedFields   []ProblemField
+		expectedSameAsIn bool
+	}{
+		"problem": {
+			givenErr:         problem,
+			expectedStatus:   http.StatusConflict,
+			expectedSameAsIn: true,
+		},
+		"http error": {
+			givenErr:       &HTTPError{Code: http.StatusNotFound, Err: errors.New("no such user")},
+			expectedStatus: http.StatusNotFound,
+			expectedDetail: "no such user",
+		},
+		"server error": {
+			givenErr:       errors.New("database is down"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		"binding errors": {
+			givenErr: BindingErrors{
+				{Source: "query", Field: "Page", Key: "page", Value: "x", Reason: "must be a whole number"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "The request has invalid fields.",
+			expectedFields: []ProblemField{{Field: "page", Source: "query", Message: "must be a whole number"}},
+		},
+		"validation errors": {
+			givenErr: ValidationErrors{
+				{Field: "Name", Key: "name", Rule: "required"},
+				{Field: "Age", Key: "age", Rule: "gte", Param: "18"},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedDetail: "The request has invalid fields.",
+			expectedFields: []ProblemField{
+				{Field: "name", Rule: "required", Message: "is required"},
+				{Field: "age", Rule: "gte", Message: "must be greater than or equal to 18"},
+			},
+		},
+		"param error": {
+			givenErr:       &ParamError{Source: "path", Key: "id", Value: "x", Err: errors.New("not a number")},
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "The request has invalid fields.",
+			expectedFields: []ProblemField{{Field: "id", Source: "path", Message: "not a number"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := NewProblem(tt.givenErr)
+			if tt.expectedSameAsIn {
+				if p != problem {
+					t.Errorf("Expected the problem in the chain, Actual: %+v", p)
+				}
+				return
+			}
+			if p.Status != tt.expectedStatus {
+				t.Errorf("Expected status: %d, Actual: %d", tt.expectedStatus, p.Status)
+			}
+			if p.Detail != tt.expectedDetail {
+				t.Errorf("Expected detail: %q, Actual: %q", tt.expectedDetail, p.Detail)
+			}
+			fields, _ := p.Extensions["errors"].([]ProblemField)
+			if !reflect.DeepEqual(fields, tt.expectedFields) {
+				t.Errorf("Expected fields: %+v, Actual: %+v", tt.expectedFields, fields)
+			}
+		})
+	}
+}
+
+func Test_CTXProblem(t *testing.T) {
+	rr := httptest.NewRecorder()
+	c := &CTX{W: &responseWriterWrapper{ResponseWriter: rr}, R: httptest.NewRequest("GET", "/", nil)}
+	c.Problem(&Problem{Status: http.StatusTooManyRequests, Detail: "slow down"})
+
+	testhelpers.VerifyResponse(t, rr, testhelpers.ExpectedResponse{
+		Code:   http.StatusTooManyRequests,
+		Header: HeaderProblemJSON,
+		Body:   `{"detail":"slow down","status":429,"title":"Too Many Requests","type":"about:blank"}` + "\n",
+	})
+}
+
+func Test_ErrorProblem(t *testing.T) {
+	type signup struct {
+		Name string `json:"name" validate:"required"`
+		Age  int    `json:"age"`
+	}
+
+	tests := map[string]struct {
+		givenAccept      string
+		givenBody        string
+		expectedResponse testhelpers.ExpectedResponse
+	}{
+		"binding error": {
+			givenAccept: "application/json",
+			givenBody:   `{"name":"Ada","age":"old"}`,
+			expectedResponse: testhelpers.ExpectedResponse{
+				Code:   http.StatusBadRequest,
+				Header: HeaderProblemJSON,
+			},
+		},
+		"validation error": {
+			givenAccept: "application/problem+json",
+			givenBody:   `{"age":36}`,
+			expectedResponse: testhelpers.ExpectedResponse{
+				Code:   http.StatusUnprocessableEntity,
+				Header: HeaderProblemJSON,
+				Body:   `{"detail":"The request has invalid fields.","errors":[{"field":"name","rule":"required","message":"is required"}],"status":422,"title":"Unprocessable Entity","type":"about:blank"}` + "\n",
+			},
+		},
+		"plain text client": {
+			givenAccept: "text/plain, */*;q=0.1",
+			givenBody:   `{"age":36}`,
+			expectedResponse: testhelpers.ExpectedResponse{
+				Code:   http.StatusUnprocessableEntity,
+				Header: HeaderPlain_UTF8,
+				Body:   "Name failed on the required rule\n",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := New()
+			e.POST("/", func(c *CTX, ctx context.Context) {
+				var s signup
+				err := c.BindJSON(&s)
+				if err == nil {
+					err = Validate(&s)
+				}
+				c.Error(err)
+			})
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.givenBody))
+			req.Header.Set("Content-Type", HeaderJSON)
+			req.Header.Set("Accept", tt.givenAccept)
+			e.ServeHTTP(rr, req)
+
+			if tt.expectedResponse.Body == "" {
+				tt.expectedResponse.Body = rr.Body.String()
+				var p Problem
+				if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+					t.Fatalf("Expected a problem, Actual: %s", rr.Body.String())
+				}
+				if p.Extensions["errors"] == nil {
+					t.Errorf("Expected an errors extension, Actual: %s", rr.Body.String())
+				}
+			}
+			testhelpers.VerifyResponse(t, rr, tt.expectedResponse)
+		})
+	}
+}
+
+func Test_ErrorProblemHidesServerErrors(t *testing.T) {
+	e := New()
+	e.GET("/", func(c *CTX, ctx context.Context) {
+		c.Error(errors.New("database is down"))
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+	e.ServeHTTP(rr, req)
+
+	testhelpers.VerifyResponse(t, rr, testhelpers.ExpectedResponse{
+		Code:   http.StatusInternalServerError,
+		Header: HeaderProblemJSON,
+		Body:   `{"status":500,"title":"Internal Server Error","type":"about:blank"}` + "\n",
+	})
+}
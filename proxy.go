@@ -0,0 +1,206 @@
+package ron
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// forwardedHop is one entry of a proxy chain. addr is invalid for entries
+// that aren't IP addresses, such as obfuscated RFC 7239 identifiers.
+type forwardedHop struct {
+	addr  netip.Addr
+	proto string
+	host  string
+}
+
+// SetTrustedProxies lists the proxies, as CIDRs or single addresses, whose
+// forwarding headers are believed. With none configured, which is the
+// default, forwarding headers are ignored and ClientIP is the peer address.
+func (e *Engine) SetTrustedProxies(proxies ...string) error {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	e.trustedProxies = prefixes
+	return nil
+}
+
+func (e *Engine) isTrustedProxy(addr netip.Addr) bool {
+	if e == nil || !addr.IsValid() {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range e.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client. When the peer is a trusted
+// proxy the chain in Forwarded, X-Forwarded-For or X-Real-IP (the first one
+// present) is walked from right to left and the first untrusted address is
+// returned. Otherwise the peer address from RemoteAddr is used.
+func (c *CTX) ClientIP() string {
+	hop, _ := c.resolveClient()
+	if !hop.addr.IsValid() {
+		host, _, err := net.SplitHostPort(c.R.RemoteAddr)
+		if err != nil {
+			return c.R.RemoteAddr
+		}
+		return host
+	}
+	return hop.addr.String()
+}
+
+// Scheme returns "https" or "http" as seen by the client. Behind a trusted
+// proxy it comes from the Forwarded proto parameter or the last value of
+// X-Forwarded-Proto, the one set by the proxy in front of the server.
+func (c *CTX) Scheme() string {
+	if hop, proxied := c.resolveClient(); proxied {
+		if hop.proto != "" {
+			return strings.ToLower(hop.proto)
+		}
+		if proto := lastHeaderToken(c.R.Header, "X-Forwarded-Proto"); proto != "" {
+			return strings.ToLower(proto)
+		}
+	}
+	if c.R.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the host requested by the client. Behind a trusted proxy it
+// comes from the Forwarded host parameter or the last value of
+// X-Forwarded-Host, as values on its left may come from the client.
+func (c *CTX) Host() string {
+	if hop, proxied := c.resolveClient(); proxied {
+		if hop.host != "" {
+			return hop.host
+		}
+		if host := lastHeaderToken(c.R.Header, "X-Forwarded-Host"); host != "" {
+			return host
+		}
+	}
+	return c.R.Host
+}
+
+// resolveClient returns the hop describing the client and whether it was
+// taken from forwarding headers.
+func (c *CTX) resolveClient() (forwardedHop, bool) {
+	remote := forwardedHop{addr: parseNode(c.R.RemoteAddr)}
+	if !c.E.isTrustedProxy(remote.addr) {
+		return remote, false
+	}
+
+	hops := forwardedHops(c.R)
+	if len(hops) == 0 {
+		return remote, false
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !hops[i].addr.IsValid() {
+			break
+		}
+		client = hops[i]
+		if !c.E.isTrustedProxy(hops[i].addr) {
+			break
+		}
+	}
+	return client, true
+}
+
+func forwardedHops(r *http.Request) []forwardedHop {
+	var hops []forwardedHop
+
+	if values := r.Header.Values("Forwarded"); len(values) > 0 {
+		for _, element := range splitQuoted(strings.Join(values, ","), ',') {
+			var hop forwardedHop
+			for _, pair := range splitQuoted(element, ';') {
+				name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				value = strings.Trim(value, `"`)
+				switch strings.ToLower(name) {
+				case "for":
+					hop.addr = parseNode(value)
+				case "proto":
+					hop.proto = value
+				case "host":
+					hop.host = value
+				}
+			}
+			hops = append(hops, hop)
+		}
+		return hops
+	}
+
+	for _, node := range headerTokens(r.Header, "X-Forwarded-For") {
+		hops = append(hops, forwardedHop{addr: parseNode(node)})
+	}
+	if len(hops) > 0 {
+		return hops
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		hops = append(hops, forwardedHop{addr: parseNode(realIP)})
+	}
+	return hops
+}
+
+// parseNode parses an address with an optional port, including the
+// bracketed IPv6 form used by RFC 7239.
+func parseNode(node string) netip.Addr {
+	node = strings.TrimSpace(node)
+	if addrPort, err := netip.ParseAddrPort(node); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(node, "["), "]"))
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// splitQuoted splits s on sep, ignoring separators inside quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func lastHeaderToken(h http.Header, name string) string {
+	if tokens := headerTokens(h, name); len(tokens) > 0 {
+		return tokens[len(tokens)-1]
+	}
+	return ""
+}
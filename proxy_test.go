@@ -0,0 +1,118 @@
+package ron
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_SetTrustedProxies(t *testing.T) {
+	e := New()
+	if err := e.SetTrustedProxies("10.0.0.0/8", "192.168.1.1", "::1"); err != nil {
+		t.Fatalf("SetTrustedProxies() failed: %v", err)
+	}
+	if err := e.SetTrustedProxies("not-an-ip"); err == nil {
+		t.Error("Expected error for an invalid proxy")
+	}
+}
+
+func Test_ClientIP(t *testing.T) {
+	tests := map[string]struct {
+		remoteAddr     string
+		header         http.Header
+		expectedIP     string
+		expectedScheme string
+		expectedHost   string
+	}{
+		"untrusted peer ignores headers": {
+			remoteAddr:     "203.0.113.9:1234",
+			header:         http.Header{"X-Forwarded-For": {"1.1.1.1"}, "X-Forwarded-Proto": {"https"}},
+			expectedIP:     "203.0.113.9",
+			expectedScheme: "http",
+			expectedHost:   "example.com",
+		},
+		"trusted peer without headers": {
+			remoteAddr:     "10.0.0.2:1234",
+			expectedIP:     "10.0.0.2",
+			expectedScheme: "http",
+			expectedHost:   "example.com",
+		},
+		"x-forwarded-for right to left": {
+			remoteAddr: "10.0.0.2:1234",
+			header: http.Header{
+				"X-Forwarded-For":   {"6.6.6.6, 198.51.100.7", "10.0.0.5"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"api.example.com"},
+			},
+			expectedIP:     "198.51.100.7",
+			expectedScheme: "https",
+			expectedHost:   "api.example.com",
+		},
+		"spoofed x-forwarded-proto and host": {
+			remoteAddr: "10.0.0.2:1234",
+			header: http.Header{
+				"X-Forwarded-For":   {"198.51.100.7"},
+				"X-Forwarded-Proto": {"https, http"},
+				"X-Forwarded-Host":  {"evil.example", "api.example.com"},
+			},
+			expectedIP:     "198.51.100.7",
+			expectedScheme: "http",
+			expectedHost:   "api.example.com",
+		},
+		"all hops trusted": {
+			remoteAddr:     "10.0.0.2:1234",
+			header:         http.Header{"X-Forwarded-For": {"10.0.0.9, 10.0.0.5"}},
+			expectedIP:     "10.0.0.9",
+			expectedScheme: "http",
+			expectedHost:   "example.com",
+		},
+		"forwarded takes precedence": {
+			remoteAddr: "10.0.0.2:1234",
+			header: http.Header{
+				"Forwarded":       {`for=192.0.2.60;proto=https;host=shop.example, for="[2001:db8::17]:4711";proto=http, for=10.0.0.5`},
+				"X-Forwarded-For": {"1.1.1.1"},
+			},
+			expectedIP:     "2001:db8::17",
+			expectedScheme: "http",
+			expectedHost:   "example.com",
+		},
+		"forwarded obfuscated node": {
+			remoteAddr:     "10.0.0.2:1234",
+			header:         http.Header{"Forwarded": {"for=_hidden, for=10.0.0.5"}},
+			expectedIP:     "10.0.0.5",
+			expectedScheme: "http",
+			expectedHost:   "example.com",
+		},
+		"x-real-ip": {
+			remoteAddr:     "[::1]:1234",
+			header:         http.Header{"X-Real-Ip": {"198.51.100.7"}},
+			expectedIP:     "198.51.100.7",
+			expectedScheme: "http",
+			expectedHost:   "example.com",
+		},
+	}
+
+	e := New()
+	e.SetTrustedProxies("10.0.0.0/8", "::1")
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			c := &CTX{R: req, E: e}
+
+			if ip := c.ClientIP(); ip != tt.expectedIP {
+				t.Errorf("Expected IP: %s, Actual: %s", tt.expectedIP, ip)
+			}
+			if scheme := c.Scheme(); scheme != tt.expectedScheme {
+				t.Errorf("Expected scheme: %s, Actual: %s", tt.expectedScheme, scheme)
+			}
+			if host := c.Host(); host != tt.expectedHost {
+				t.Errorf("Expected host: %s, Actual: %s", tt.expectedHost, host)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package ron
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryValues parses the query string once per request.
+func (c *CTX) queryValues() url.Values {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.query == nil {
+		c.query = c.R.URL.Query()
+	}
+	return c.query
+}
+
+func (c *CTX) Query(key string) string {
+	return c.queryValues().Get(key)
+}
+
+// QueryDefault returns the first value for key, or fallback when the key is
+// absent.
+func (c *CTX) QueryDefault(key, fallback string) string {
+	if values, ok := c.queryValues()[key]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return fallback
+}
+
+// QueryInt returns the key parsed as an int, or fallback when it is absent or
+// empty. A value that isn't a number returns a *ParamError.
+func (c *CTX) QueryInt(key string, fallback int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback, &ParamError{Source: "query", Key: key, Value: value, Err: err}
+	}
+	return i, nil
+}
+
+// QueryBool returns the key parsed with strconv.ParseBool, or fallback when
+// it is absent or empty.
+func (c *CTX) QueryBool(key string, fallback bool) (bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback, &ParamError{Source: "query", Key: key, Value: value, Err: err}
+	}
+	return b, nil
+}
+
+// QueryTime returns the key parsed with layout, or fallback when it is absent
+// or empty.
+func (c *CTX) QueryTime(key, layout string, fallback time.Time) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return fallback, &ParamError{Source: "query", Key: key, Value: value, Err: err}
+	}
+	return t, nil
+}
+
+// QueryArray returns every value of key, including the ones sent as key[].
+func (c *CTX) QueryArray(key string) []string {
+	q := c.queryValues()
+	values := append([]string{}, q[key]...)
+	return append(values, q[key+"[]"]...)
+}
+
+// QueryMap collects keys like filter[name]=x into a map keyed by the part
+// between brackets.
+func (c *CTX) QueryMap(key string) map[string]string {
+	m := make(map[string]string)
+	prefix := key + "["
+	for k, values := range c.queryValues() {
+		if !strings.HasPrefix(k, prefix) || !strings.HasSuffix(k, "]") || len(values) == 0 {
+			continue
+		}
+		m[k[len(prefix):len(k)-1]] = values[0]
+	}
+	return m
+}
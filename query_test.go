@@ -0,0 +1,94 @@
+package ron
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newQueryCTX(target string) *CTX {
+	return &CTX{
+		W: &responseWriterWrapper{ResponseWriter: httptest.NewRecorder()},
+		R: httptest.NewRequest("GET", target, nil),
+	}
+}
+
+func Test_QueryDefault(t *testing.T) {
+	c := newQueryCTX("/?foo=bar&empty=")
+
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{"present", "foo", "bar"},
+		{"present but empty", "empty", ""},
+		{"absent", "missing", "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := c.QueryDefault(tt.key, "fallback"); actual != tt.expected {
+				t.Errorf("Expected: %q, Actual: %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_QueryTyped(t *testing.T) {
+	c := newQueryCTX("/?page=2&bad=x&active=true&since=2024-01-02")
+
+	if page, err := c.QueryInt("page", 1); err != nil || page != 2 {
+		t.Errorf("Expected page: 2, Actual: %d, %v", page, err)
+	}
+	if limit, err := c.QueryInt("limit", 20); err != nil || limit != 20 {
+		t.Errorf("Expected limit: 20, Actual: %d, %v", limit, err)
+	}
+	if active, err := c.QueryBool("active", false); err != nil || !active {
+		t.Errorf("Expected active: true, Actual: %v, %v", active, err)
+	}
+	since, err := c.QueryTime("since", time.DateOnly, time.Time{})
+	if expected := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC); err != nil || !since.Equal(expected) {
+		t.Errorf("Expected since: %v, Actual: %v, %v", expected, since, err)
+	}
+
+	_, err = c.QueryInt("bad", 1)
+	var paramErr *ParamError
+	if !errors.As(err, &paramErr) || paramErr.Key != "bad" || paramErr.Value != "x" {
+		t.Fatalf("Expected ParamError for bad, Actual: %v", err)
+	}
+	if code := StatusCode(err); code != http.StatusBadRequest {
+		t.Errorf("Expected status code: %d, Actual: %d", http.StatusBadRequest, code)
+	}
+}
+
+func Test_QueryArray(t *testing.T) {
+	c := newQueryCTX("/?tag=a&tag=b&tag[]=c")
+
+	expected := []string{"a", "b", "c"}
+	if actual := c.QueryArray("tag"); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected: %v, Actual: %v", expected, actual)
+	}
+}
+
+func Test_QueryMap(t *testing.T) {
+	c := newQueryCTX("/?filter[name]=foo&filter[status]=active&filter=x&other[a]=b")
+
+	expected := map[string]string{"name": "foo", "status": "active"}
+	if actual := c.QueryMap("filter"); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected: %v, Actual: %v", expected, actual)
+	}
+}
+
+func Test_QueryCached(t *testing.T) {
+	c := newQueryCTX("/?foo=bar")
+	c.Query("foo")
+	c.R.URL.RawQuery = "foo=baz"
+
+	if actual := c.Query("foo"); actual != "bar" {
+		t.Errorf("Expected cached value: bar, Actual: %s", actual)
+	}
+}
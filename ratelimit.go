@@ -0,0 +1,306 @@
+package ron
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// RateLimiter decides whether the request identified by key may
+	// proceed.
+	RateLimiter interface {
+		Allow(ctx context.Context, key string) (RateLimitResult, error)
+	}
+
+	// RateLimitResult is the decision of a RateLimiter.
+	RateLimitResult struct {
+		Allowed bool
+		// Limit is the number of requests allowed in a burst or a window.
+		Limit int
+		// Remaining is the number of requests still allowed right now.
+		Remaining int
+		// Reset is how long until the limit is fully available again.
+		Reset time.Duration
+		// RetryAfter is how long until a denied request would be allowed.
+		RetryAfter time.Duration
+	}
+
+	// RateLimitState is what a limiter keeps for a key. Its meaning depends
+	// on the algorithm, so stores should treat it as opaque.
+	RateLimitState struct {
+		Time     time.Time
+		Value    float64
+		Previous float64
+	}
+
+	// RateLimitStore keeps the state of every key, for example in memory
+	// or in a database shared by several servers.
+	RateLimitStore interface {
+		// Update calls fn with the state of key, or a zero state if key is
+		// unknown or has expired, and saves the state fn leaves until ttl
+		// after now. Updates of the same key must not interleave.
+		Update(ctx context.Context, key string, now time.Time, ttl time.Duration, fn func(*RateLimitState)) error
+	}
+
+	// RateLimitOptions configure the limiters built by NewTokenBucket and
+	// NewSlidingWindow.
+	RateLimitOptions func(*rateLimiter)
+
+	// RateLimitKeyFunc returns the key a request is limited under. An empty
+	// key leaves the request unlimited.
+	RateLimitKeyFunc func(r *http.Request) string
+
+	rateLimiter struct {
+		store RateLimitStore
+		now   func() time.Time
+		ttl   time.Duration
+		step  func(state *RateLimitState, now time.Time) RateLimitResult
+	}
+
+	// MemoryRateLimitStore is a RateLimitStore for a single server. Expired
+	// keys are removed as other keys are updated.
+	MemoryRateLimitStore struct {
+		mu        sync.Mutex
+		entries   map[string]*memoryRateLimitEntry
+		lastSweep time.Time
+	}
+
+	memoryRateLimitEntry struct {
+		state   RateLimitState
+		expires time.Time
+	}
+)
+
+// memorySweepInterval is how often MemoryRateLimitStore looks for expired
+// keys.
+const memorySweepInterval = time.Minute
+
+// WithRateLimitStore keeps the state in store. It defaults to a new
+// MemoryRateLimitStore.
+func WithRateLimitStore(store RateLimitStore) RateLimitOptions {
+	return func(l *rateLimiter) {
+		l.store = store
+	}
+}
+
+// WithRateLimitClock replaces time.Now, for tests.
+func WithRateLimitClock(now func() time.Time) RateLimitOptions {
+	return func(l *rateLimiter) {
+		l.now = now
+	}
+}
+
+// NewTokenBucket returns a limiter giving every key a bucket of burst
+// tokens, refilled with one token every interval. A request takes a token,
+// and is denied when the bucket is empty.
+func NewTokenBucket(burst int, interval time.Duration, opts ...RateLimitOptions) RateLimiter {
+	l := newRateLimiter(time.Duration(burst)*interval, opts)
+	l.step = func(state *RateLimitState, now time.Time) RateLimitResult {
+		tokens := float64(burst)
+		if !state.Time.IsZero() {
+			tokens = math.Min(tokens, state.Value+float64(now.Sub(state.Time))/float64(interval))
+		}
+
+		result := RateLimitResult{Limit: burst}
+		if tokens >= 1 {
+			tokens--
+			result.Allowed = true
+		} else {
+			result.RetryAfter = time.Duration((1 - tokens) * float64(interval))
+		}
+		state.Time, state.Value = now, tokens
+
+		result.Remaining = int(tokens)
+		result.Reset = time.Duration((float64(burst) - tokens) * float64(interval))
+		return result
+	}
+	return l
+}
+
+// NewSlidingWindow returns a limiter allowing every key limit requests in
+// any window of the given length. It approximates the window from the
+// counts of the current and the previous fixed windows, weighting the
+// previous count by how much of it the sliding window still covers.
+func NewSlidingWindow(limit int, window time.Duration, opts ...RateLimitOptions) RateLimiter {
+	l := newRateLimiter(2*window, opts)
+	l.step = func(state *RateLimitState, now time.Time) RateLimitResult {
+		start := now.Truncate(window)
+		if !state.Time.Equal(start) {
+			previous := 0.0
+			if state.Time.Equal(start.Add(-window)) {
+				previous = state.Value
+			}
+			*state = RateLimitState{Time: start, Previous: previous}
+		}
+
+		elapsed := now.Sub(start)
+		weight := 1 - float64(elapsed)/float64(window)
+		count := state.Previous*weight + state.Value
+
+		result := RateLimitResult{Limit: limit, Reset: window - elapsed}
+		if count+1 <= float64(limit) {
+			state.Value++
+			count++
+			result.Allowed = true
+		} else if state.Value+1 <= float64(limit) {
+			// Wait for enough of the previous window to slide out.
+			wait := float64(window) * (1 - (float64(limit)-1-state.Value)/state.Previous)
+			result.RetryAfter = time.Duration(wait) - elapsed
+		} else {
+			// Wait for the next window and for enough of this one to slide
+			// out.
+			wait := float64(window) * (1 - (float64(limit)-1)/state.Value)
+			result.RetryAfter = window - elapsed + time.Duration(wait)
+		}
+		result.Remaining = max(0, int(float64(limit)-count))
+		return result
+	}
+	return l
+}
+
+func newRateLimiter(ttl time.Duration, opts []RateLimitOptions) *rateLimiter {
+	l := &rateLimiter{now: time.Now, ttl: ttl}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(l)
+		}
+	}
+	if l.store == nil {
+		l.store = NewMemoryRateLimitStore()
+	}
+	return l
+}
+
+func (l *rateLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	now := l.now()
+	var result RateLimitResult
+	err := l.store.Update(ctx, key, now, l.ttl, func(state *RateLimitState) {
+		result = l.step(state, now)
+	})
+	return result, err
+}
+
+// NewMemoryRateLimitStore returns an empty MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{entries: make(map[string]*memoryRateLimitEntry)}
+}
+
+func (s *MemoryRateLimitStore) Update(ctx context.Context, key string, now time.Time, ttl time.Duration, fn func(*RateLimitState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		entry = &memoryRateLimitEntry{}
+		s.entries[key] = entry
+	}
+	fn(&entry.state)
+	entry.expires = now.Add(ttl)
+	return nil
+}
+
+// Len returns the number of keys in the store, including expired keys not
+// removed yet.
+func (s *MemoryRateLimitStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// KeyByClientIP limits requests by CTX.ClientIP.
+func KeyByClientIP(r *http.Request) string {
+	client := &CTX{R: r}
+	if c := FromContext(r.Context()); c != nil {
+		client.E = c.E
+	}
+	return client.ClientIP()
+}
+
+// KeyByHeader limits requests by the value of a header, such as an API key.
+// Requests without the header are not limited, so it is usually combined
+// with a limit by client IP.
+func KeyByHeader(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// KeyByRoute gives every route its own limit for each key returned by key,
+// so a strict limit on the login form doesn't use up the limit of the rest
+// of the site. Routes are told apart by their pattern, so /users/1 and
+// /users/2 share the limit of /users/{id}, and requests matching no route
+// share one limit.
+func KeyByRoute(key RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		k := key(r)
+		if k == "" {
+			return ""
+		}
+		pattern := r.Pattern
+		if c := FromContext(r.Context()); pattern == "" && c != nil && c.E != nil {
+			pattern = c.E.routePattern(r)
+		}
+		return pattern + " " + k
+	}
+}
+
+// RateLimitMiddleware limits requests with limiter, under the key returned
+// by key, which defaults to KeyByClientIP. Every limited response has
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers. Denied
+// requests get 429 Too Many Requests through CTX.Error with a Retry-After
+// header. If the store fails the request is let through and the error
+// logged, so an outage of a shared store doesn't take the site down.
+//
+//	login := e.GROUP("/login")
+//	login.USE(e.RateLimitMiddleware(ron.NewSlidingWindow(5, time.Minute), nil))
+func (e *Engine) RateLimitMiddleware(limiter RateLimiter, key RateLimitKeyFunc) Middleware {
+	if key == nil {
+		key = KeyByClientIP
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := limiter.Allow(r.Context(), k)
+			if err != nil {
+				slog.Error("rate limit failed", "error", err, "path", r.URL.Path)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			if !result.Allowed {
+				h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+				writeError(w, r, &HTTPError{Code: http.StatusTooManyRequests})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
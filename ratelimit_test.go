@@ -0,0 +1,271 @@
+package ron
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock tests move by hand.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+type rateLimitStep struct {
+	advance           time.Duration
+	expectedAllowed   bool
+	expectedRemaining int
+	expectedRetry     time.Duration
+}
+
+func runRateLimitSteps(t *testing.T, limiter RateLimiter, clock *fakeClock, steps []rateLimitStep) {
+	t.Helper()
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		result, err := limiter.Allow(context.Background(), "key")
+		if err != nil {
+			t.Fatalf("step %d: Allow() failed: %v", i, err)
+		}
+		if result.Allowed != step.expectedAllowed {
+			t.Errorf("step %d: Expected allowed: %t, Actual: %t", i, step.expectedAllowed, result.Allowed)
+		}
+		if result.Remaining != step.expectedRemaining {
+			t.Errorf("step %d: Expected remaining: %d, Actual: %d", i, step.expectedRemaining, result.Remaining)
+		}
+		if result.RetryAfter != step.expectedRetry {
+			t.Errorf("step %d: Expected retry after: %v, Actual: %v", i, step.expectedRetry, result.RetryAfter)
+		}
+	}
+}
+
+func Test_TokenBucket(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewTokenBucket(3, time.Second, WithRateLimitClock(clock.Now))
+
+	runRateLimitSteps(t, limiter, clock, []rateLimitStep{
+		{0, true, 2, 0},
+		{0, true, 1, 0},
+		{0, true, 0, 0},
+		{0, false, 0, time.Second},
+		{500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+		{500 * time.Millisecond, true, 0, 0},
+		{10 * time.Second, true, 2, 0},
+	})
+
+	result, _ := limiter.Allow(context.Background(), "key")
+	if result.Limit != 3 || result.Reset != 2*time.Second {
+		t.Errorf("Expected limit 3 and reset 2s, Actual: %+v", result)
+	}
+}
+
+func Test_SlidingWindow(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewSlidingWindow(4, time.Minute, WithRateLimitClock(clock.Now))
+
+	runRateLimitSteps(t, limiter, clock, []rateLimitStep{
+		{0, true, 3, 0},
+		{0, true, 2, 0},
+		{0, true, 1, 0},
+		{0, true, 0, 0},
+		// The window is full until the next one starts, and then until a
+		// quarter of it has slid out.
+		{30 * time.Second, false, 0, 45 * time.Second},
+		{30 * time.Second, false, 0, 15 * time.Second},
+		{15 * time.Second, true, 0, 0},
+		// Half of the previous window still counts: 2 + 1 = 3 of 4, so one
+		// more fits.
+		{15 * time.Second, true, 0, 0},
+		// 4 × 25/60 + 2 is over 3 until a quarter of the window is left.
+		{5 * time.Second, false, 0, 10 * time.Second},
+		// Two windows later nothing counts any more.
+		{2 * time.Minute, true, 3, 0},
+	})
+}
+
+func Test_RateLimitKeysAreIndependent(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewTokenBucket(1, time.Minute, WithRateLimitClock(clock.Now))
+
+	for _, key := range []string{"a", "b"} {
+		if result, _ := limiter.Allow(context.Background(), key); !result.Allowed {
+			t.Errorf("Expected the first request of %s to be allowed", key)
+		}
+	}
+	if result, _ := limiter.Allow(context.Background(), "a"); result.Allowed {
+		t.Error("Expected the second request of a to be denied")
+	}
+}
+
+func Test_MemoryRateLimitStoreExpires(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	count := func(state *RateLimitState) { state.Value++ }
+
+	store.Update(context.Background(), "a", now, time.Second, count)
+	store.Update(context.Background(), "a", now, time.Second, count)
+	var value float64
+	store.Update(context.Background(), "a", now.Add(2*time.Second), time.Second, func(state *RateLimitState) {
+		value = state.Value
+	})
+	if value != 0 {
+		t.Errorf("Expected an expired key to start over, Actual: %v", value)
+	}
+
+	store.Update(context.Background(), "b", now, time.Second, count)
+	store.Update(context.Background(), "c", now.Add(2*time.Minute), time.Second, count)
+	if n := store.Len(); n != 1 {
+		t.Errorf("Expected expired keys to be swept, Actual: %d keys", n)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Update(context.Context, string, time.Time, time.Duration, func(*RateLimitState)) error {
+	return errors.New("store is down")
+}
+
+func Test_RateLimitMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		givenLimiter      func(clock *fakeClock) RateLimiter
+		givenKey          RateLimitKeyFunc
+		givenRequests     int
+		givenHeader       string
+		expectedCode      int
+		expectedHeaders   map[string]string
+		expectedNoHeaders []string
+	}{
+		"allowed": {
+			givenLimiter: func(clock *fakeClock) RateLimiter {
+				return NewTokenBucket(2, time.Second, WithRateLimitClock(clock.Now))
+			},
+			givenRequests: 1,
+			expectedCode:  http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "1",
+				"RateLimit-Reset":     "1",
+			},
+			expectedNoHeaders: []string{"Retry-After"},
+		},
+		"denied": {
+			givenLimiter: func(clock *fakeClock) RateLimiter {
+				return NewSlidingWindow(2, time.Minute, WithRateLimitClock(clock.Now))
+			},
+			givenRequests: 3,
+			expectedCode:  http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "90",
+			},
+		},
+		"by header": {
+			givenLimiter: func(clock *fakeClock) RateLimiter {
+				return NewTokenBucket(1, time.Second, WithRateLimitClock(clock.Now))
+			},
+			givenKey:      KeyByHeader("X-API-Key"),
+			givenHeader:   "secret",
+			givenRequests: 2,
+			expectedCode:  http.StatusTooManyRequests,
+		},
+		"without the key header": {
+			givenLimiter: func(clock *fakeClock) RateLimiter {
+				return NewTokenBucket(1, time.Second, WithRateLimitClock(clock.Now))
+			},
+			givenKey:          KeyByHeader("X-API-Key"),
+			givenRequests:     2,
+			expectedCode:      http.StatusOK,
+			expectedNoHeaders: []string{"RateLimit-Limit"},
+		},
+		"store failure": {
+			givenLimiter: func(clock *fakeClock) RateLimiter {
+				return NewTokenBucket(1, time.Second, WithRateLimitStore(failingStore{}))
+			},
+			givenRequests:     2,
+			expectedCode:      http.StatusOK,
+			expectedNoHeaders: []string{"RateLimit-Limit"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			e := New()
+			e.USE(e.RateLimitMiddleware(tt.givenLimiter(clock), tt.givenKey))
+			e.GET("/", func(c *CTX, ctx context.Context) {
+				c.W.Write([]byte("handler"))
+			})
+
+			var rr *httptest.ResponseRecorder
+			for i := 0; i < tt.givenRequests; i++ {
+				rr = httptest.NewRecorder()
+				req := httptest.NewRequest("GET", "/", nil)
+				if tt.givenHeader != "" {
+					req.Header.Set("X-API-Key", tt.givenHeader)
+				}
+				e.ServeHTTP(rr, req)
+			}
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, rr.Code)
+			}
+			for k, v := range tt.expectedHeaders {
+				if actual := rr.Header().Get(k); actual != v {
+					t.Errorf("Expected %s: %s, Actual: %s", k, v, actual)
+				}
+			}
+			for _, k := range tt.expectedNoHeaders {
+				if actual := rr.Header().Get(k); actual != "" {
+					t.Errorf("Expected no %s, Actual: %s", k, actual)
+				}
+			}
+		})
+	}
+}
+
+func Test_KeyByRoute(t *testing.T) {
+	clock := newFakeClock()
+	e := New()
+	e.USE(e.RateLimitMiddleware(NewTokenBucket(1, time.Minute, WithRateLimitClock(clock.Now)), KeyByRoute(KeyByClientIP)))
+	e.GET("/a", func(c *CTX, ctx context.Context) {})
+	e.GET("/b", func(c *CTX, ctx context.Context) {})
+	e.GET("/users/{id}", func(c *CTX, ctx context.Context) {})
+	api := e.GROUP("/api")
+	api.GET("/users/{id}", func(c *CTX, ctx context.Context) {})
+
+	expected := []struct {
+		path string
+		code int
+	}{
+		{"/a", http.StatusOK},
+		{"/b", http.StatusOK},
+		{"/a", http.StatusTooManyRequests},
+		{"/users/1", http.StatusOK},
+		{"/users/2", http.StatusTooManyRequests},
+		{"/api/users/1", http.StatusOK},
+		{"/api/users/2", http.StatusTooManyRequests},
+	}
+	for _, tt := range expected {
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+		if rr.Code != tt.code {
+			t.Errorf("%s: Expected status code: %d, Actual: %d", tt.path, tt.code, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package ron
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseWriter is the writer of a CTX, created once per request in
+// ServeHTTP and shared by middleware and handlers. It records the status,
+// the bytes written and the time to first byte. It implements
+// http.Flusher, http.Hijacker and http.Pusher exactly when the writer it
+// wraps does, and Unwrap for http.ResponseController.
+type ResponseWriter interface {
+	http.ResponseWriter
+	io.ReaderFrom
+	// FlushError flushes, returning http.ErrNotSupported when the wrapped
+	// writer can't.
+	FlushError() error
+	Unwrap() http.ResponseWriter
+	// Status returns the status code sent to the client, or 0 if nothing
+	// has been written yet.
+	Status() int
+	// Size returns the number of body bytes written.
+	Size() int64
+	// Written reports whether the header has been sent.
+	Written() bool
+	// TimeToFirstByte returns how long the request took to commit its
+	// header.
+	TimeToFirstByte() time.Duration
+
+	wrapper() *responseWriterWrapper
+}
+
+type (
+	responseWriterWrapper struct {
+		http.ResponseWriter
+		status        int
+		size          int64
+		start         time.Time
+		firstByte     time.Time
+		headerWritten bool
+	}
+
+	// flusher, hijacker and pusher add an optional interface to the
+	// wrapper, for the writers that have it.
+	flusher  struct{ w *responseWriterWrapper }
+	hijacker struct{ w *responseWriterWrapper }
+	pusher   struct{ w *responseWriterWrapper }
+)
+
+// newResponseWriter wraps w, or returns it when it is already wrapped. The
+// wrapper is picked so it claims the optional interfaces of w and no
+// others, as middleware often look for them with a type assertion.
+func newResponseWriter(w http.ResponseWriter) ResponseWriter {
+	if rw, ok := w.(ResponseWriter); ok {
+		return rw
+	}
+	rw := &responseWriterWrapper{ResponseWriter: w, start: time.Now()}
+
+	f, h, p := flusher{rw}, hijacker{rw}, pusher{rw}
+	canFlush, canHijack, canPush := implements[http.Flusher](w), implements[http.Hijacker](w), implements[http.Pusher](w)
+	switch {
+	case canFlush && canHijack && canPush:
+		return struct {
+			*responseWriterWrapper
+			flusher
+			hijacker
+			pusher
+		}{rw, f, h, p}
+	case canFlush && canHijack:
+		return struct {
+			*responseWriterWrapper
+			flusher
+			hijacker
+		}{rw, f, h}
+	case canFlush && canPush:
+		return struct {
+			*responseWriterWrapper
+			flusher
+			pusher
+		}{rw, f, p}
+	case canHijack && canPush:
+		return struct {
+			*responseWriterWrapper
+			hijacker
+			pusher
+		}{rw, h, p}
+	case canFlush:
+		return struct {
+			*responseWriterWrapper
+			flusher
+		}{rw, f}
+	case canHijack:
+		return struct {
+			*responseWriterWrapper
+			hijacker
+		}{rw, h}
+	case canPush:
+		return struct {
+			*responseWriterWrapper
+			pusher
+		}{rw, p}
+	}
+	return rw
+}
+
+func (w *responseWriterWrapper) wrapper() *responseWriterWrapper {
+	return w
+}
+
+func (w *responseWriterWrapper) WriteHeader(code int) {
+	if w.headerWritten {
+		return
+	}
+	// Informational responses may be followed by the final one.
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.markWritten(code)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriterWrapper) Write(b []byte) (int, error) {
+	if !w.headerWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *responseWriterWrapper) ReadFrom(src io.Reader) (int64, error) {
+	if !w.headerWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(w.ResponseWriter, src)
+	}
+	w.size += n
+	return n, err
+}
+
+// FlushError flushes the underlying writer, returning http.ErrNotSupported
+// when it can't. http.ResponseController prefers it over Flush.
+func (w *responseWriterWrapper) FlushError() error {
+	if err := http.NewResponseController(w.ResponseWriter).Flush(); err != nil {
+		return err
+	}
+	if !w.headerWritten {
+		w.markWritten(http.StatusOK)
+	}
+	return nil
+}
+
+func (f flusher) Flush() {
+	f.w.FlushError()
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(h.w.ResponseWriter).Hijack()
+	if err == nil && !h.w.headerWritten {
+		h.w.markWritten(http.StatusSwitchingProtocols)
+	}
+	return conn, brw, err
+}
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	var w http.ResponseWriter = p.w.ResponseWriter
+	for w != nil {
+		if pusher, ok := w.(http.Pusher); ok {
+			return pusher.Push(target, opts)
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	return http.ErrNotSupported
+}
+
+func (w *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriterWrapper) markWritten(code int) {
+	w.headerWritten = true
+	w.status = code
+	w.firstByte = time.Now()
+}
+
+// Status returns the status code sent to the client, or 0 if nothing has
+// been written yet.
+func (w *responseWriterWrapper) Status() int {
+	return w.status
+}
+
+// Size returns the number of body bytes written.
+func (w *responseWriterWrapper) Size() int64 {
+	return w.size
+}
+
+// Written reports whether the header has been sent.
+func (w *responseWriterWrapper) Written() bool {
+	return w.headerWritten
+}
+
+// TimeToFirstByte returns how long the request took to commit its header.
+func (w *responseWriterWrapper) TimeToFirstByte() time.Duration {
+	if !w.headerWritten || w.start.IsZero() {
+		return 0
+	}
+	return w.firstByte.Sub(w.start)
+}
+
+// canFlush reports whether the writer chain below w can actually flush.
+func canFlush(w http.ResponseWriter) bool {
+	return implements[http.Flusher](w)
+}
+
+// implements reports whether w, or a writer it wraps through Unwrap, is a
+// T. Our own wrapper is looked through, as it may claim T for the writer
+// below it.
+func implements[T any](w http.ResponseWriter) bool {
+	for w != nil {
+		if rw, ok := w.(ResponseWriter); ok {
+			w = rw.Unwrap()
+			continue
+		}
+		if _, ok := w.(T); ok {
+			return true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = u.Unwrap()
+	}
+	return false
+}
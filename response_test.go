@@ -0,0 +1,159 @@
+package ron
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_responseWriterRecords(t *testing.T) {
+	rr := httptest.NewRecorder()
+	w := newResponseWriter(rr)
+
+	if w.Written() || w.Status() != 0 {
+		t.Errorf("Expected unwritten writer, Actual: status %d", w.Status())
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte("foo"))
+	w.ReadFrom(strings.NewReader("bar"))
+
+	if w.Status() != http.StatusCreated || rr.Code != http.StatusCreated {
+		t.Errorf("Expected status code: %d, Actual: %d", http.StatusCreated, w.Status())
+	}
+	if w.Size() != 6 {
+		t.Errorf("Expected size: 6, Actual: %d", w.Size())
+	}
+	if w.TimeToFirstByte() <= 0 {
+		t.Errorf("Expected positive time to first byte, Actual: %v", w.TimeToFirstByte())
+	}
+	if rr.Body.String() != "foobar" {
+		t.Errorf("Expected body: foobar, Actual: %s", rr.Body.String())
+	}
+}
+
+func Test_responseWriterImplicitStatus(t *testing.T) {
+	w := newResponseWriter(httptest.NewRecorder())
+	w.Write([]byte("foo"))
+
+	if w.Status() != http.StatusOK {
+		t.Errorf("Expected status code: %d, Actual: %d", http.StatusOK, w.Status())
+	}
+}
+
+func Test_responseWriterOptionalInterfaces(t *testing.T) {
+	rr := httptest.NewRecorder()
+	w := newResponseWriter(nonFlusher{rr})
+	rc := http.NewResponseController(w)
+
+	if err := rc.Flush(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Expected Flush: %v, Actual: %v", http.ErrNotSupported, err)
+	}
+	if _, _, err := rc.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Expected Hijack: %v, Actual: %v", http.ErrNotSupported, err)
+	}
+	if _, ok := any(w).(http.Flusher); ok {
+		t.Error("Expected the wrapper not to claim http.Flusher")
+	}
+	if _, ok := any(w).(http.Hijacker); ok {
+		t.Error("Expected the wrapper not to claim http.Hijacker")
+	}
+	if _, ok := any(w).(http.Pusher); ok {
+		t.Error("Expected the wrapper not to claim http.Pusher")
+	}
+	if w.Written() {
+		t.Error("Expected failed Flush to leave the header unwritten")
+	}
+
+	w = newResponseWriter(rr)
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		t.Errorf("Expected Flush to succeed, Actual: %v", err)
+	}
+	if !rr.Flushed || w.Status() != http.StatusOK {
+		t.Errorf("Expected flushed 200 response, Actual: flushed=%v status=%d", rr.Flushed, w.Status())
+	}
+	if w.Unwrap() != rr {
+		t.Error("Expected Unwrap to return the underlying writer")
+	}
+}
+
+// hijackRecorder is a recorder that can also be hijacked.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (h hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.conn, bufio.NewReadWriter(bufio.NewReader(h.conn), bufio.NewWriter(h.conn)), nil
+}
+
+func Test_responseWriterClaimsWrappedInterfaces(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	tests := map[string]struct {
+		given            http.ResponseWriter
+		expectedFlusher  bool
+		expectedHijacker bool
+	}{
+		"none":               {given: nonFlusher{httptest.NewRecorder()}},
+		"flusher":            {given: httptest.NewRecorder(), expectedFlusher: true},
+		"flusher, hijacker":  {given: hijackRecorder{httptest.NewRecorder(), server}, expectedFlusher: true, expectedHijacker: true},
+		"hijacker unwrapped": {given: nonFlusher{hijackRecorder{httptest.NewRecorder(), server}}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := newResponseWriter(tt.given)
+			if _, ok := w.(http.Flusher); ok != tt.expectedFlusher {
+				t.Errorf("Expected http.Flusher: %v, Actual: %v", tt.expectedFlusher, ok)
+			}
+			if _, ok := w.(http.Hijacker); ok != tt.expectedHijacker {
+				t.Errorf("Expected http.Hijacker: %v, Actual: %v", tt.expectedHijacker, ok)
+			}
+			if _, ok := w.(http.Pusher); ok {
+				t.Error("Expected no http.Pusher")
+			}
+			if newResponseWriter(w) != w {
+				t.Error("Expected a wrapped writer to be reused")
+			}
+			if h, ok := w.(http.Hijacker); ok {
+				if _, _, err := h.Hijack(); err != nil || w.Status() != http.StatusSwitchingProtocols {
+					t.Errorf("Expected a 101 hijack, Actual: %d, %v", w.Status(), err)
+				}
+			}
+		})
+	}
+}
+
+func Test_responseWriterSharedPerRequest(t *testing.T) {
+	e := New()
+	var middlewareWriter http.ResponseWriter
+	e.USE(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			middlewareWriter = w
+			next.ServeHTTP(w, r)
+		})
+	})
+	e.GET("/", func(c *CTX, ctx context.Context) {
+		if c.W != middlewareWriter {
+			t.Error("Expected handler and middleware to share the response writer")
+		}
+		c.W.WriteHeader(http.StatusAccepted)
+	})
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	e.ServeHTTP(rr, req)
+
+	if status := middlewareWriter.(ResponseWriter).Status(); status != http.StatusAccepted {
+		t.Errorf("Expected status code: %d, Actual: %d", http.StatusAccepted, status)
+	}
+}
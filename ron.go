@@ -1,14 +1,19 @@
 package ron
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,21 +24,30 @@ type (
 
 	Middleware func(http.Handler) http.Handler
 
-	responseWriterWrapper struct {
-		http.ResponseWriter
-		http.Flusher
-		headerWritten bool
-	}
-
 	CTX struct {
-		W *responseWriterWrapper
+		W ResponseWriter
 		R *http.Request
 		E *Engine
+
+		mu    sync.RWMutex
+		keys  map[string]any
+		query url.Values
+
+		formChecked bool
+		formErr     error
+		// bound is shared by the sources of a Bind call.
+		bound map[string]bool
 	}
 
 	Config struct {
 		Timeout  time.Duration
 		LogLevel slog.Level
+		// CookieKeys sign and encrypt cookies. The first key is used for new
+		// cookies and every key is tried when reading, so keys can be rotated
+		// by prepending the new one.
+		CookieKeys [][]byte
+		Binding    BindingConfig
+		Upload     UploadConfig
 	}
 
 	Engine struct {
@@ -42,6 +56,14 @@ type (
 		groupMux   map[string]*groupMux
 		Config     *Config
 		Render     *Render
+		// ErrorHandler renders errors passed to CTX.Error. It defaults to a
+		// Problem for clients accepting JSON and a plain text response for
+		// the others, using the status from StatusCode.
+		ErrorHandler func(*CTX, error)
+
+		trustedProxies []netip.Prefix
+		binders        map[string]BinderFunc
+		routes         []*Route
 	}
 
 	groupMux struct {
@@ -53,33 +75,18 @@ type (
 )
 
 const (
-	RequestID        string = "request_id"
-	HeaderJSON       string = "application/json"
-	HeaderHTML_UTF8  string = "text/html; charset=utf-8"
-	HeaderCSS_UTF8   string = "text/css; charset=utf-8"
-	HeaderAppJS      string = "application/javascript"
-	HeaderPlain_UTF8 string = "text/plain; charset=utf-8"
+	RequestID         string = "request_id"
+	Principal         string = "principal"
+	HeaderJSON        string = "application/json"
+	HeaderXML         string = "application/xml"
+	HeaderProblemJSON string = "application/problem+json"
+	HeaderHTML_UTF8   string = "text/html; charset=utf-8"
+	HeaderCSS_UTF8    string = "text/css; charset=utf-8"
+	HeaderAppJS       string = "application/javascript"
+	HeaderPlain_UTF8  string = "text/plain; charset=utf-8"
+	HeaderEventStream string = "text/event-stream"
 )
 
-func (w *responseWriterWrapper) WriteHeader(code int) {
-	if !w.headerWritten {
-		w.headerWritten = true
-		w.ResponseWriter.WriteHeader(code)
-	}
-}
-
-func (w *responseWriterWrapper) Write(b []byte) (int, error) {
-	if !w.headerWritten {
-		w.headerWritten = true
-		w.ResponseWriter.WriteHeader(http.StatusOK)
-	}
-	return w.ResponseWriter.Write(b)
-}
-
-func (w *responseWriterWrapper) Flush() {
-	w.ResponseWriter.(http.Flusher).Flush()
-}
-
 func defaultEngine() *Engine {
 	return &Engine{
 		mux:      http.NewServeMux(),
@@ -87,6 +94,8 @@ func defaultEngine() *Engine {
 		Config: &Config{
 			Timeout:  time.Second * 30,
 			LogLevel: slog.LevelDebug,
+			Binding:  defaultBindingConfig(),
+			Upload:   defaultUploadConfig(),
 		},
 	}
 }
@@ -116,8 +125,41 @@ func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	handler = createStack(e.middleware...)(handler)
-	rw := &responseWriterWrapper{ResponseWriter: w}
-	handler.ServeHTTP(rw, r)
+
+	c := &CTX{W: newResponseWriter(w), E: e}
+	c.R = r.WithContext(context.WithValue(r.Context(), ctxKey{}, c))
+	defer func() {
+		// net/http only removes the files of a form parsed on the request
+		// it created, not on our copy of it.
+		if c.R.MultipartForm != nil {
+			c.R.MultipartForm.RemoveAll()
+		}
+	}()
+	handler.ServeHTTP(c.W, c.R)
+}
+
+// routePattern returns the pattern of the route r matches, such as
+// "GET /api/users/{id}", or "" when it matches none. Middleware runs before
+// the mux, so r.Pattern isn't set yet there.
+func (e *Engine) routePattern(r *http.Request) string {
+	_, pattern := e.mux.Handler(r)
+	for prefix, group := range e.groupMux {
+		if pattern != prefix+"/" {
+			continue
+		}
+		u := *r.URL
+		u.Path = strings.TrimPrefix(r.URL.Path, prefix)
+		u.RawPath = strings.TrimPrefix(r.URL.RawPath, prefix)
+		stripped := r.WithContext(r.Context())
+		stripped.URL = &u
+
+		_, pattern = group.mux.Handler(stripped)
+		if method, path, ok := strings.Cut(pattern, " "); ok {
+			return method + " " + prefix + path
+		}
+		return pattern
+	}
+	return pattern
 }
 
 func (e *Engine) Run(addr string) error {
@@ -139,18 +181,34 @@ func (e *Engine) USE(middleware Middleware) {
 	e.middleware = append(e.middleware, middleware)
 }
 
-func (e *Engine) GET(path string, handler func(*CTX, context.Context)) {
-	e.mux.HandleFunc(fmt.Sprintf("GET %s", path), func(w http.ResponseWriter, r *http.Request) {
-		rw := &responseWriterWrapper{ResponseWriter: w}
-		handler(&CTX{W: rw, R: r, E: e}, r.Context())
-	})
+func (e *Engine) GET(path string, handler func(*CTX, context.Context), opts ...RouteOptions) {
+	e.handle(e.mux, http.MethodGet, "", path, handler, opts)
+}
+
+func (e *Engine) POST(path string, handler func(*CTX, context.Context), opts ...RouteOptions) {
+	e.handle(e.mux, http.MethodPost, "", path, handler, opts)
 }
 
-func (e *Engine) POST(path string, handler func(*CTX, context.Context)) {
-	e.mux.HandleFunc(fmt.Sprintf("POST %s", path), func(w http.ResponseWriter, r *http.Request) {
-		rw := &responseWriterWrapper{ResponseWriter: w}
-		handler(&CTX{W: rw, R: r, E: e}, r.Context())
-	})
+// handle registers handler on mux and records the route, with the prefix of
+// its group, in the engine's route registry.
+func (e *Engine) handle(mux *http.ServeMux, method, prefix, path string, handler func(*CTX, context.Context), opts []RouteOptions) {
+	mux.HandleFunc(fmt.Sprintf("%s %s", method, path), e.handlerFunc(handler))
+	e.addRoute(method, prefix+path, opts)
+}
+
+// handlerFunc adapts a ron handler to the mux, reusing the CTX and response
+// writer created in ServeHTTP so middleware and handlers share their state.
+// The request is refreshed because middleware may have replaced it.
+func (e *Engine) handlerFunc(handler func(*CTX, context.Context)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := FromContext(r.Context())
+		if c == nil {
+			c = &CTX{E: e}
+		}
+		c.W = newResponseWriter(w)
+		c.R = r
+		handler(c, r.Context())
+	}
 }
 
 func (e *Engine) GROUP(prefix string) *groupMux {
@@ -171,18 +229,12 @@ func (g *groupMux) USE(middleware Middleware) {
 	g.middleware = append(g.middleware, middleware)
 }
 
-func (g *groupMux) GET(path string, handler func(*CTX, context.Context)) {
-	g.mux.HandleFunc(fmt.Sprintf("GET %s", path), func(w http.ResponseWriter, r *http.Request) {
-		rw := &responseWriterWrapper{ResponseWriter: w}
-		handler(&CTX{W: rw, R: r, E: g.engine}, r.Context())
-	})
+func (g *groupMux) GET(path string, handler func(*CTX, context.Context), opts ...RouteOptions) {
+	g.engine.handle(g.mux, http.MethodGet, g.prefix, path, handler, opts)
 }
 
-func (g *groupMux) POST(path string, handler func(*CTX, context.Context)) {
-	g.mux.HandleFunc(fmt.Sprintf("POST %s", path), func(w http.ResponseWriter, r *http.Request) {
-		rw := &responseWriterWrapper{ResponseWriter: w}
-		handler(&CTX{W: rw, R: r, E: g.engine}, r.Context())
-	})
+func (g *groupMux) POST(path string, handler func(*CTX, context.Context), opts ...RouteOptions) {
+	g.engine.handle(g.mux, http.MethodPost, g.prefix, path, handler, opts)
 }
 
 // Static serves static files from a specified directory, accessible through a defined URL path.
@@ -221,28 +273,38 @@ func (c *CTX) Path(key string) string {
 	return c.R.PathValue(key)
 }
 
-func (c *CTX) Query(key string) string {
-	return c.R.URL.Query().Get(key)
+func (c *CTX) JSON(code int, data any) {
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(data); err != nil {
+		http.Error(c.W, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.W.Header().Set("Content-Type", HeaderJSON)
+	c.W.WriteHeader(code)
+	buf.WriteTo(c.W)
 }
 
-func (c *CTX) JSON(code int, data any) {
-	c.W.Header().Set("Content-Type", "application/json")
-	encoder := json.NewEncoder(c.W)
-	if err := encoder.Encode(data); err != nil {
+// XML writes data as an XML document with the status code.
+func (c *CTX) XML(code int, data any) {
+	buf := bytes.NewBufferString(xml.Header)
+	if err := xml.NewEncoder(buf).Encode(data); err != nil {
 		http.Error(c.W, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	c.W.Header().Set("Content-Type", HeaderXML)
 	c.W.WriteHeader(code)
+	buf.WriteTo(c.W)
 }
 
 func (c *CTX) HTML(code int, name string, td *TemplateData) {
-	c.W.Header().Set("Content-Type", "text/html; charset=utf-8")
-	err := c.E.Render.Template(c.W, name, td)
-	if err != nil {
+	buf := new(bytes.Buffer)
+	if err := c.E.Render.Template(buf, name, td); err != nil {
 		http.Error(c.W, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	c.W.Header().Set("Content-Type", "text/html; charset=utf-8")
 	c.W.WriteHeader(code)
+	buf.WriteTo(c.W)
 }
 
 func newLogger(level slog.Level) {
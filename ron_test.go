@@ -389,6 +389,15 @@ func Test_JSON(t *testing.T) {
 				Body:   `{"bar":"bar","something":30,"car":null}` + "\n",
 			},
 		},
+		"status code": {
+			givenCode: http.StatusCreated,
+			givenData: Foo{Bar: "bar", Taz: 30, Car: nil},
+			expectedResponse: testhelpers.ExpectedResponse{
+				Code:   http.StatusCreated,
+				Header: HeaderJSON,
+				Body:   `{"bar":"bar","something":30,"car":null}` + "\n",
+			},
+		},
 		"invalid JSON": {
 			givenCode: http.StatusOK,
 			givenData: make(chan int),
@@ -432,6 +441,16 @@ func Test_HTML(t *testing.T) {
 				Body:   "<h1>foo</h1><h2>bar</h2>",
 			},
 		},
+		"status code": {
+			givenCode:     http.StatusUnprocessableEntity,
+			givenTemplate: "page.index.gohtml",
+			givenData:     &TemplateData{Data: Data{"heading1": "foo", "heading2": "bar"}},
+			expectedResponse: testhelpers.ExpectedResponse{
+				Code:   http.StatusUnprocessableEntity,
+				Header: HeaderHTML_UTF8,
+				Body:   "<h1>foo</h1><h2>bar</h2>",
+			},
+		},
 		"template not found": {
 			givenCode:     http.StatusOK,
 			givenTemplate: "nonexistent.gohtml",
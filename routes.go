@@ -0,0 +1,84 @@
+package ron
+
+import "net/http"
+
+type (
+	// Route describes a registered route. Besides the method and path, its
+	// fields are optional documentation used by OpenAPI, apart from
+	// Streaming.
+	Route struct {
+		Method string
+		// Path is the pattern of the route, including the prefix of its
+		// group, such as /api/users/{id}.
+		Path        string
+		Summary     string
+		Description string
+		OperationID string
+		Tags        []string
+		// Request is a value of the struct the handler binds. Its path,
+		// query, header, cookie, json and form tags and its validation rules
+		// document the parameters and the body.
+		Request any
+		// Responses maps status codes to a value of the type written with
+		// them. A nil value documents a response without a body.
+		Responses  map[int]any
+		Deprecated bool
+		// Hidden leaves the route out of the OpenAPI document.
+		Hidden bool
+		// Streaming marks a route serving Server-Sent Events or WebSockets.
+		// TimeOutMiddleware and CompressMiddleware leave its requests
+		// alone, as the connection outlives a regular request.
+		Streaming bool
+	}
+
+	// RouteOptions sets the documentation of a route when it is registered:
+	//
+	//	e.POST("/users", createUser, func(r *ron.Route) {
+	//		r.Summary = "Create a user"
+	//		r.Request = CreateUser{}
+	//		r.Responses = map[int]any{http.StatusCreated: User{}}
+	//	})
+	RouteOptions func(*Route)
+)
+
+func (e *Engine) addRoute(method, path string, opts []RouteOptions) {
+	route := &Route{Method: method, Path: path}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(route)
+		}
+	}
+	e.routes = append(e.routes, route)
+}
+
+// matchRoute returns the registered route r matches, or nil. Middleware
+// runs before the mux, so the route is found from the pattern the mux
+// would pick.
+func (e *Engine) matchRoute(r *http.Request) *Route {
+	pattern := e.routePattern(r)
+	if pattern == "" {
+		return nil
+	}
+	for _, route := range e.routes {
+		if route.Method+" "+route.Path == pattern {
+			return route
+		}
+	}
+	return nil
+}
+
+// isStreamingRoute reports whether r is for a route marked Streaming. It
+// is decided by the route alone, never by headers the client controls.
+func (e *Engine) isStreamingRoute(r *http.Request) bool {
+	route := e.matchRoute(r)
+	return route != nil && route.Streaming
+}
+
+// Routes returns the registered routes in the order they were added.
+func (e *Engine) Routes() []Route {
+	routes := make([]Route, len(e.routes))
+	for i, route := range e.routes {
+		routes[i] = *route
+	}
+	return routes
+}
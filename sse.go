@@ -0,0 +1,172 @@
+package ron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSEStream writes Server-Sent Events to a client. It is safe for concurrent
+// use, so a heartbeat can run alongside the handler sending events.
+type SSEStream struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	ctx    context.Context
+	mu     sync.Mutex
+	nextID string
+}
+
+var ErrStreamingNotSupported = errors.New("response writer does not support streaming")
+
+// SSE switches the response to a text/event-stream and returns the stream to
+// write events to. The stream ends when the handler returns or the client
+// disconnects, which is reported through Done. Mark the route Streaming so
+// TimeOutMiddleware and CompressMiddleware leave it alone.
+func (c *CTX) SSE() (*SSEStream, error) {
+	if !canFlush(c.W) {
+		return nil, ErrStreamingNotSupported
+	}
+
+	h := c.W.Header()
+	h.Set("Content-Type", HeaderEventStream)
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	h.Del("Content-Length")
+
+	c.W.WriteHeader(http.StatusOK)
+	if err := c.W.FlushError(); err != nil {
+		return nil, err
+	}
+
+	return &SSEStream{w: c.W, rc: http.NewResponseController(c.W), ctx: c.R.Context()}, nil
+}
+
+// LastEventID returns the Last-Event-ID sent by a reconnecting client.
+func (c *CTX) LastEventID() string {
+	return c.R.Header.Get("Last-Event-ID")
+}
+
+// Done is closed when the client goes away or the request is cancelled.
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// ID sets the id sent with the next event.
+func (s *SSEStream) ID(id string) {
+	s.mu.Lock()
+	s.nextID = sanitizeSSEField(id)
+	s.mu.Unlock()
+}
+
+// Retry tells the client how long to wait before reconnecting.
+func (s *SSEStream) Retry(d time.Duration) error {
+	return s.write("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+}
+
+// Event sends an event with the given name, which may be empty for the
+// default "message" event. Strings and byte slices are sent as they are, any
+// other value is encoded as JSON.
+func (s *SSEStream) Event(name string, data any) error {
+	payload, err := sseData(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+	if s.nextID != "" {
+		b.WriteString("id: " + s.nextID + "\n")
+		s.nextID = ""
+	}
+	if name = sanitizeSSEField(name); name != "" {
+		b.WriteString("event: " + name + "\n")
+	}
+	payload = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(payload)
+	for _, line := range strings.Split(payload, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	return s.writeLocked(b.String())
+}
+
+// Data sends an unnamed event.
+func (s *SSEStream) Data(data any) error {
+	return s.Event("", data)
+}
+
+// Comment sends a comment line, which clients ignore. It is useful to keep
+// idle connections open through proxies.
+func (s *SSEStream) Comment(text string) error {
+	return s.write(": " + sanitizeSSEField(text) + "\n\n")
+}
+
+// Heartbeat sends a comment every interval until the client disconnects or
+// the returned stop function is called.
+func (s *SSEStream) Heartbeat(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	quit := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-quit:
+				return
+			case <-ticker.C:
+				if err := s.Comment("heartbeat"); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return func() { once.Do(func() { close(quit) }) }
+}
+
+func (s *SSEStream) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeLocked(msg)
+}
+
+func (s *SSEStream) writeLocked(msg string) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func sseData(data any) (string, error) {
+	switch v := data.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+// sanitizeSSEField drops line breaks so a value can't inject extra fields.
+func sanitizeSSEField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
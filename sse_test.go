@@ -0,0 +1,133 @@
+package ron
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type nonFlusher struct {
+	http.ResponseWriter
+}
+
+func Test_StreamingRouteTimeout(t *testing.T) {
+	e := New(func(e *Engine) {
+		e.Config.Timeout = 20 * time.Millisecond
+	})
+	e.USE(e.TimeOutMiddleware())
+	slow := func(c *CTX, ctx context.Context) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			c.W.Write([]byte("done"))
+		case <-ctx.Done():
+		}
+	}
+	e.GET("/report", slow)
+	e.GET("/events", slow, func(r *Route) {
+		r.Streaming = true
+	})
+
+	tests := map[string]struct {
+		path         string
+		expectedCode int
+	}{
+		"regular route asking for a stream": {"/report", http.StatusGatewayTimeout},
+		"streaming route":                   {"/events", http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("Accept", HeaderEventStream)
+			req.Header.Set("Upgrade", "websocket")
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func Test_SSE(t *testing.T) {
+	e := New()
+	e.USE(e.TimeOutMiddleware())
+	e.GET("/events", func(c *CTX, ctx context.Context) {
+		stream, err := c.SSE()
+		if err != nil {
+			t.Fatalf("SSE() failed: %v", err)
+		}
+		stream.Retry(3 * time.Second)
+		stream.ID("1")
+		stream.Event("greeting", "hello\nworld")
+		stream.Data(Data{"foo": "bar"})
+		stream.Comment("bye")
+	}, func(r *Route) {
+		r.Streaming = true
+	})
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	req.Header.Set("Accept", HeaderEventStream)
+	e.ServeHTTP(rr, req)
+
+	expected := "retry: 3000\n\n" +
+		"id: 1\nevent: greeting\ndata: hello\ndata: world\n\n" +
+		"data: {\"foo\":\"bar\"}\n\n" +
+		": bye\n\n"
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code: %d, Actual: %d", http.StatusOK, rr.Code)
+	}
+	if header := rr.Header().Get("Content-Type"); header != HeaderEventStream {
+		t.Errorf("Expected Content-Type: %s, Actual: %s", HeaderEventStream, header)
+	}
+	if !rr.Flushed {
+		t.Error("Expected flushed response")
+	}
+	if rr.Body.String() != expected {
+		t.Errorf("Expected body: %q, Actual: %q", expected, rr.Body.String())
+	}
+}
+
+func Test_SSENotSupported(t *testing.T) {
+	rr := httptest.NewRecorder()
+	c := &CTX{
+		W: &responseWriterWrapper{ResponseWriter: nonFlusher{rr}},
+		R: httptest.NewRequest("GET", "/", nil),
+	}
+
+	if _, err := c.SSE(); err != ErrStreamingNotSupported {
+		t.Errorf("Expected: %v, Actual: %v", ErrStreamingNotSupported, err)
+	}
+}
+
+func Test_SSEDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rr := httptest.NewRecorder()
+	c := &CTX{
+		W: &responseWriterWrapper{ResponseWriter: rr},
+		R: httptest.NewRequest("GET", "/", nil).WithContext(ctx),
+	}
+
+	stream, err := c.SSE()
+	if err != nil {
+		t.Fatalf("SSE() failed: %v", err)
+	}
+	stop := stream.Heartbeat(time.Millisecond)
+	defer stop()
+
+	cancel()
+	select {
+	case <-stream.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected stream to be done after disconnect")
+	}
+
+	if err := stream.Data("late"); err == nil {
+		t.Error("Expected error writing to a disconnected stream")
+	}
+}
@@ -0,0 +1,66 @@
+package ron
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+type ctxKey struct{}
+
+// FromContext returns the CTX of the request the context belongs to, or nil
+// when the request wasn't served by an Engine. Middleware use it to reach the
+// per-request store:
+//
+//	ron.FromContext(r.Context()).Set("user", user)
+func FromContext(ctx context.Context) *CTX {
+	c, _ := ctx.Value(ctxKey{}).(*CTX)
+	return c
+}
+
+// Set stores a value for the rest of the request.
+func (c *CTX) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys == nil {
+		c.keys = make(map[string]any)
+	}
+	c.keys[key] = value
+}
+
+// Get returns the value stored under key and whether it exists.
+func (c *CTX) Get(key string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	value, ok := c.keys[key]
+	return value, ok
+}
+
+// MustGet returns the value stored under key and panics if it doesn't exist.
+func (c *CTX) MustGet(key string) any {
+	value, ok := c.Get(key)
+	if !ok {
+		panic(fmt.Sprintf("ron: key %q does not exist", key))
+	}
+	return value
+}
+
+// Value returns the value stored under key as a T. The boolean is false when
+// the key doesn't exist or holds another type.
+func Value[T any](c *CTX, key string) (T, bool) {
+	value, _ := c.Get(key)
+	t, ok := value.(T)
+	return t, ok
+}
+
+// MustValue is like Value but panics when the key is missing or has another
+// type.
+func MustValue[T any](c *CTX, key string) T {
+	t, ok := Value[T](c, key)
+	if !ok {
+		panic(fmt.Sprintf("ron: key %q is not a %v", key, reflect.TypeFor[T]()))
+	}
+	return t
+}
@@ -0,0 +1,100 @@
+package ron
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Store(t *testing.T) {
+	e := New()
+	e.USE(e.RequestIdMiddleware())
+	e.USE(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			FromContext(r.Context()).Set("user", "foo")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	api := e.GROUP("/api")
+	api.USE(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			FromContext(r.Context()).Set("count", 3)
+			next.ServeHTTP(w, r)
+		})
+	})
+	api.GET("/index", func(c *CTX, ctx context.Context) {
+		if FromContext(ctx) != c {
+			t.Error("Expected the handler CTX in the request context")
+		}
+		if user := c.MustGet("user"); user != "foo" {
+			t.Errorf("Expected user: foo, Actual: %v", user)
+		}
+		if count, ok := Value[int](c, "count"); !ok || count != 3 {
+			t.Errorf("Expected count: 3, Actual: %v", count)
+		}
+		if id, ok := Value[string](c, RequestID); !ok || id != "abc" {
+			t.Errorf("Expected request id: abc, Actual: %v", id)
+		}
+		c.W.WriteHeader(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/index", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	e.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code: %d, Actual: %d", http.StatusOK, rr.Code)
+	}
+}
+
+func Test_Value(t *testing.T) {
+	c := &CTX{}
+	c.Set("foo", "bar")
+
+	tests := []struct {
+		name     string
+		key      string
+		expected bool
+	}{
+		{"existing key", "foo", true},
+		{"missing key", "baz", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Value[string](c, tt.key); ok != tt.expected {
+				t.Errorf("Expected: %v, Actual: %v", tt.expected, ok)
+			}
+		})
+	}
+
+	if _, ok := Value[int](c, "foo"); ok {
+		t.Error("Expected type mismatch to report false")
+	}
+}
+
+func Test_MustGet(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(c *CTX)
+	}{
+		{"MustGet", func(c *CTX) { c.MustGet("missing") }},
+		{"MustValue", func(c *CTX) { MustValue[int](c, "foo") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%s did not panic", tt.name)
+				}
+			}()
+			c := &CTX{}
+			c.Set("foo", "bar")
+			tt.fn(c)
+		})
+	}
+}
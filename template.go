@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"html/template"
+	"io"
 	"io/fs"
 	"log/slog"
 	"net/http"
@@ -19,6 +20,8 @@ type (
 	TemplateData struct {
 		Data  Data
 		Pages Pages
+		// Form re-populates a form rendered again after a failed bind.
+		Form *Form
 	}
 
 	RenderOptions func(*Render)
@@ -63,7 +66,7 @@ func defaultIfEmpty(fallback, value string) string {
 	return value
 }
 
-func (re *Render) Template(w http.ResponseWriter, tmpl string, td *TemplateData) error {
+func (re *Render) Template(w io.Writer, tmpl string, td *TemplateData) error {
 	var tc templateCache
 	var err error
 
@@ -0,0 +1,60 @@
+package ron
+
+import (
+	"context"
+	"net/http"
+)
+
+// Typed adapts fn to a handler that can be registered with GET and POST on
+// an Engine or a group. For every request it:
+//
+//  1. binds a Req, a struct, from the path, query, body and the other
+//     sources read by CTX.Bind
+//  2. validates it with Validate
+//  3. calls fn with the request context
+//  4. writes the Resp with CTX.Negotiate, as JSON or XML
+//
+// Errors from any step go through CTX.Error, so they render with the status
+// of StatusCode: 400 for bad input, 422 for failed validation and whatever
+// fn returns, such as an HTTPError. The response is 200 OK unless Resp has
+// a StatusCode method, as in:
+//
+//	type Created struct{ ID string `json:"id"` }
+//
+//	func (Created) StatusCode() int { return http.StatusCreated }
+//
+//	e.POST("/users", ron.Typed(func(ctx context.Context, req CreateUser) (Created, error) {
+//		id, err := users.Create(ctx, req.Name)
+//		return Created{ID: id}, err
+//	}))
+//
+// A 204 No Content status writes no body.
+func Typed[Req, Resp any](fn func(context.Context, Req) (Resp, error)) func(*CTX, context.Context) {
+	return func(c *CTX, ctx context.Context) {
+		var req Req
+		err := c.Bind(&req)
+		if err == nil && !c.bindingConfig().Validate {
+			err = Validate(&req)
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		resp, err := fn(ctx, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		code := http.StatusOK
+		if sc, ok := any(resp).(interface{ StatusCode() int }); ok {
+			code = sc.StatusCode()
+		}
+		if code == http.StatusNoContent {
+			c.W.WriteHeader(code)
+			return
+		}
+		c.Negotiate(code, resp)
+	}
+}
@@ -0,0 +1,103 @@
+package ron
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type createUser struct {
+	Team   string `path:"team"`
+	Notify bool   `query:"notify"`
+	Name   string `json:"name" validate:"required"`
+}
+
+type createdUser struct {
+	ID     string `json:"id" xml:"id"`
+	Team   string `json:"team" xml:"team"`
+	Notify bool   `json:"notify" xml:"notify"`
+}
+
+func (createdUser) StatusCode() int { return http.StatusCreated }
+
+func Test_Typed(t *testing.T) {
+	e := New()
+	api := e.GROUP("/api")
+	api.POST("/teams/{team}/users", Typed(func(ctx context.Context, req createUser) (createdUser, error) {
+		if req.Name == "taken" {
+			return createdUser{}, &HTTPError{Code: http.StatusConflict, Err: errors.New("name taken")}
+		}
+		return createdUser{ID: "u-" + req.Name, Team: req.Team, Notify: req.Notify}, nil
+	}))
+	e.POST("/ping", Typed(func(ctx context.Context, req struct{}) (noContent, error) {
+		return noContent{}, nil
+	}))
+
+	tests := map[string]struct {
+		path         string
+		body         string
+		accept       string
+		expectedCode int
+		expectedBody string
+	}{
+		"created": {
+			path:         "/api/teams/core/users?notify=true",
+			body:         `{"name":"foo"}`,
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":"u-foo","team":"core","notify":true}` + "\n",
+		},
+		"created as xml": {
+			path:         "/api/teams/core/users",
+			body:         `{"name":"foo"}`,
+			accept:       "application/xml",
+			expectedCode: http.StatusCreated,
+			expectedBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<createdUser><id>u-foo</id><team>core</team><notify>false</notify></createdUser>`,
+		},
+		"bind error": {
+			path:         "/api/teams/core/users?notify=maybe",
+			body:         `{"name":"foo"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		"validation error": {
+			path:         "/api/teams/core/users",
+			body:         `{}`,
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		"handler error": {
+			path:         "/api/teams/core/users",
+			body:         `{"name":"taken"}`,
+			expectedCode: http.StatusConflict,
+			expectedBody: "name taken\n",
+		},
+		"no content": {
+			path:         "/ping",
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d (%s)", tt.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tt.expectedBody != "" && rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body: %q, Actual: %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+type noContent struct{}
+
+func (noContent) StatusCode() int { return http.StatusNoContent }
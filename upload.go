@@ -0,0 +1,211 @@
+package ron
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// UploadConfig limits multipart bodies. A zero limit disables that check.
+type UploadConfig struct {
+	// MaxMemory is the part of a multipart body kept in memory. Files beyond
+	// it are streamed to temporary files on disk while the body is parsed.
+	MaxMemory int64
+	// MaxFileSize is the maximum size of a single uploaded file. It is
+	// checked once the body has been parsed, so only MaxUploadSize limits
+	// how much of the body is read.
+	MaxFileSize int64
+	// MaxUploadSize is the maximum size of the whole multipart body.
+	MaxUploadSize int64
+	// AllowedTypes lists the accepted MIME types, sniffed from the content
+	// of each file rather than trusted from the client. A type may end in
+	// "/*" to accept a whole family, such as "image/*".
+	AllowedTypes []string
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+func defaultUploadConfig() UploadConfig {
+	return UploadConfig{
+		MaxMemory:     32 << 20,
+		MaxUploadSize: 64 << 20,
+	}
+}
+
+func (c *CTX) uploadConfig() UploadConfig {
+	if c.E == nil || c.E.Config == nil {
+		return defaultUploadConfig()
+	}
+	return c.E.Config.Upload
+}
+
+// FormFile returns the first file uploaded under name. It returns
+// http.ErrMissingFile when there is none.
+func (c *CTX) FormFile(name string) (*multipart.FileHeader, error) {
+	if err := c.parseMultipartForm(); err != nil {
+		return nil, err
+	}
+	if c.R.MultipartForm != nil {
+		if files := c.R.MultipartForm.File[name]; len(files) > 0 {
+			return files[0], nil
+		}
+	}
+	return nil, http.ErrMissingFile
+}
+
+// SaveUploadedFile copies an uploaded file to dst, creating its directory if
+// needed. Files the parser already spilled to disk are copied from there, so
+// large uploads never go through memory.
+func (c *CTX) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// parseMultipartForm parses a multipart body within the upload limits and
+// checks every file against them. Other bodies are parsed with ParseForm.
+// The result is kept, so a rejected upload stays rejected on later calls,
+// and a form parsed earlier by other code is checked all the same.
+func (c *CTX) parseMultipartForm() error {
+	if c.formChecked {
+		return c.formErr
+	}
+	c.formChecked = true
+	c.formErr = c.checkMultipartForm()
+	return c.formErr
+}
+
+func (c *CTX) checkMultipartForm() error {
+	config := c.uploadConfig()
+	if c.R.MultipartForm == nil {
+		mediaType, _, _ := mime.ParseMediaType(c.R.Header.Get("Content-Type"))
+		if mediaType != "multipart/form-data" {
+			if err := c.R.ParseForm(); err != nil {
+				return &HTTPError{Code: http.StatusBadRequest, Err: err}
+			}
+			return nil
+		}
+
+		if config.MaxUploadSize > 0 {
+			c.R.Body = http.MaxBytesReader(c.W, c.R.Body, config.MaxUploadSize)
+		}
+		if err := c.R.ParseMultipartForm(config.MaxMemory); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return &HTTPError{Code: http.StatusRequestEntityTooLarge, Err: fmt.Errorf("upload larger than %d bytes", maxBytesErr.Limit)}
+			}
+			return &HTTPError{Code: http.StatusBadRequest, Err: err}
+		}
+	}
+
+	for _, files := range c.R.MultipartForm.File {
+		for _, file := range files {
+			if err := checkUploadedFile(file, config); err != nil {
+				c.R.MultipartForm.RemoveAll()
+				c.R.MultipartForm = nil
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkUploadedFile enforces the per-file size cap and the MIME allowlist.
+func checkUploadedFile(file *multipart.FileHeader, config UploadConfig) error {
+	if config.MaxFileSize > 0 && file.Size > config.MaxFileSize {
+		return &HTTPError{
+			Code: http.StatusRequestEntityTooLarge,
+			Err:  fmt.Errorf("file %q larger than %d bytes", file.Filename, config.MaxFileSize),
+		}
+	}
+	if len(config.AllowedTypes) == 0 {
+		return nil
+	}
+
+	contentType, err := sniffContentType(file)
+	if err != nil {
+		return err
+	}
+	if !allowedType(contentType, config.AllowedTypes) {
+		return &HTTPError{
+			Code: http.StatusUnsupportedMediaType,
+			Err:  fmt.Errorf("file %q has unsupported type %s", file.Filename, contentType),
+		}
+	}
+	return nil
+}
+
+// sniffContentType detects the MIME type of a file from its first 512
+// bytes, ignoring the Content-Type sent by the client.
+func sniffContentType(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	return mediaType, nil
+}
+
+func allowedType(contentType string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if family, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(contentType, family+"/") {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(contentType, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// setFiles binds uploaded files to a *multipart.FileHeader or a
+// []*multipart.FileHeader field.
+func setFiles(field reflect.Value, files []*multipart.FileHeader) {
+	if len(files) == 0 {
+		return
+	}
+	if field.Type() == fileHeaderType {
+		field.Set(reflect.ValueOf(files[0]))
+		return
+	}
+	field.Set(reflect.ValueOf(files))
+}
+
+func isFileType(t reflect.Type) bool {
+	return t == fileHeaderType || t == fileHeaderSliceType
+}
@@ -0,0 +1,227 @@
+package ron
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type uploadPart struct {
+	field, filename string
+	content         []byte
+}
+
+func newUploadRequest(t *testing.T, values map[string]string, files ...uploadPart) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range values {
+		mw.WriteField(k, v)
+	}
+	for _, f := range files {
+		w, err := mw.CreateFormFile(f.field, f.filename)
+		if err != nil {
+			t.Fatalf("CreateFormFile() failed: %v", err)
+		}
+		w.Write(f.content)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func Test_BindFormFiles(t *testing.T) {
+	type profile struct {
+		Name   string                  `form:"name"`
+		Avatar *multipart.FileHeader   `form:"avatar"`
+		Docs   []*multipart.FileHeader `form:"docs"`
+		Resume *multipart.FileHeader   `form:"resume"`
+	}
+
+	binders := map[string]func(*CTX, any) error{
+		"BindForm": (*CTX).BindForm,
+		"Bind":     (*CTX).Bind,
+	}
+
+	for name, bind := range binders {
+		t.Run(name, func(t *testing.T) {
+			req := newUploadRequest(t, map[string]string{"name": "foo"},
+				uploadPart{"avatar", "avatar.png", pngHeader},
+				uploadPart{"docs", "a.txt", []byte("a")},
+				uploadPart{"docs", "b.txt", []byte("b")},
+			)
+			c := &CTX{R: req, E: New()}
+
+			var p profile
+			if err := bind(c, &p); err != nil {
+				t.Fatalf("%s() failed: %v", name, err)
+			}
+			if p.Name != "foo" {
+				t.Errorf("Expected name: foo, Actual: %s", p.Name)
+			}
+			if p.Avatar == nil || p.Avatar.Filename != "avatar.png" {
+				t.Errorf("Expected avatar.png, Actual: %+v", p.Avatar)
+			}
+			if len(p.Docs) != 2 || p.Docs[0].Filename != "a.txt" || p.Docs[1].Filename != "b.txt" {
+				t.Errorf("Expected docs a.txt and b.txt, Actual: %+v", p.Docs)
+			}
+			if p.Resume != nil {
+				t.Errorf("Expected nil resume, Actual: %+v", p.Resume)
+			}
+		})
+	}
+}
+
+func Test_FormFile(t *testing.T) {
+	content := []byte(strings.Repeat("foo", 100))
+	req := newUploadRequest(t, nil, uploadPart{"file", "foo.txt", content})
+	c := &CTX{R: req, E: New(func(e *Engine) {
+		e.Config.Upload.MaxMemory = 16
+	})}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		t.Fatalf("FormFile() failed: %v", err)
+	}
+	if _, err := c.FormFile("missing"); err != http.ErrMissingFile {
+		t.Errorf("Expected: %v, Actual: %v", http.ErrMissingFile, err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "uploads", "foo.txt")
+	if err := c.SaveUploadedFile(file, dst); err != nil {
+		t.Fatalf("SaveUploadedFile() failed: %v", err)
+	}
+	saved, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if !bytes.Equal(saved, content) {
+		t.Errorf("Expected saved file to match the upload, Actual: %d bytes", len(saved))
+	}
+}
+
+func Test_UploadLimits(t *testing.T) {
+	tests := map[string]struct {
+		config       func(*UploadConfig)
+		file         uploadPart
+		preParsed    bool
+		expectedCode int
+	}{
+		"within limits": {
+			config: func(uc *UploadConfig) {
+				uc.MaxFileSize = 1 << 10
+				uc.AllowedTypes = []string{"image/*"}
+			},
+			file: uploadPart{"file", "foo.png", pngHeader},
+		},
+		"file too large": {
+			config: func(uc *UploadConfig) {
+				uc.MaxFileSize = 8
+			},
+			file:         uploadPart{"file", "foo.png", pngHeader},
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		"upload too large": {
+			config: func(uc *UploadConfig) {
+				uc.MaxUploadSize = 64
+			},
+			file:         uploadPart{"file", "foo.txt", bytes.Repeat([]byte("a"), 128)},
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		"type not allowed": {
+			config: func(uc *UploadConfig) {
+				uc.AllowedTypes = []string{"image/png", "application/pdf"}
+			},
+			file:         uploadPart{"file", "foo.png", []byte("plain text pretending to be a picture")},
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		"type not allowed in a parsed form": {
+			config: func(uc *UploadConfig) {
+				uc.AllowedTypes = []string{"image/png"}
+			},
+			file:         uploadPart{"file", "foo.png", []byte("plain text pretending to be a picture")},
+			preParsed:    true,
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := newUploadRequest(t, nil, tt.file)
+			if tt.preParsed {
+				req.ParseMultipartForm(32 << 20)
+			}
+			c := &CTX{R: req, W: newResponseWriter(httptest.NewRecorder()), E: New(func(e *Engine) {
+				tt.config(&e.Config.Upload)
+			})}
+
+			_, err := c.FormFile("file")
+			if tt.expectedCode == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, Actual: %v", err)
+				}
+				return
+			}
+
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) || httpErr.Code != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %v", tt.expectedCode, err)
+			}
+			if req.MultipartForm != nil {
+				t.Error("Expected the rejected form to be removed")
+			}
+			if file, again := c.FormFile("file"); file != nil || again != err {
+				t.Errorf("Expected the same error on a second call, Actual: %v, %v", file, again)
+			}
+		})
+	}
+}
+
+func Test_BindFormMalformedBody(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader("a=%zz"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c := &CTX{R: req, W: newResponseWriter(httptest.NewRecorder())}
+
+	var foo struct {
+		A string `form:"a"`
+	}
+	err := c.BindForm(&foo)
+	if StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("Expected status code: %d, Actual: %v", http.StatusBadRequest, err)
+	}
+}
+
+func Test_UploadTempFilesRemoved(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	e := New(func(e *Engine) {
+		e.Config.Upload.MaxMemory = 1
+	})
+	e.POST("/", func(c *CTX, ctx context.Context) {
+		if _, err := c.FormFile("file"); err != nil {
+			t.Errorf("FormFile() failed: %v", err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) == 0 {
+			t.Error("Expected the upload to be stored in a temporary file")
+		}
+	})
+
+	req := newUploadRequest(t, nil, uploadPart{"file", "foo.txt", bytes.Repeat([]byte("a"), 1<<10)})
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected temporary files to be removed, Actual: %d left", len(entries))
+	}
+}
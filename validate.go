@@ -0,0 +1,492 @@
+package ron
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type (
+	// ValidationField is the field a rule is checked against.
+	ValidationField struct {
+		// Value is the field, with pointers already dereferenced.
+		Value reflect.Value
+		// Parent is the struct holding the field, for cross-field rules.
+		Parent reflect.Value
+		// Param is the text after "=" in the rule, if any.
+		Param string
+	}
+
+	// ValidationFunc reports whether a field satisfies a rule.
+	ValidationFunc func(f ValidationField) bool
+
+	// FieldError describes a rule a field failed.
+	FieldError struct {
+		// Field is the Go path of the field, such as Items[0].Qty.
+		Field string
+		// Key is the same path built from the form tag, falling back to the
+		// json tag and then the Go name, such as items[0].qty.
+		Key   string
+		Rule  string
+		Param string
+		Value any
+	}
+
+	// ValidationErrors lists every rule that failed. It renders as 422
+	// Unprocessable Entity.
+	ValidationErrors []FieldError
+
+	validationRule struct {
+		name  string
+		param string
+	}
+)
+
+var (
+	validationsMu sync.RWMutex
+	validations   = map[string]ValidationFunc{
+		"required": validateRequired,
+		"min":      validateMin,
+		"max":      validateMax,
+		"len":      validateLen,
+		"oneof":    validateOneOf,
+		"email":    validateEmail,
+		"url":      validateURL,
+		"regexp":   validateRegexp,
+		"uuid":     validateUUID,
+		"gt":       validateOrder(func(c int) bool { return c > 0 }),
+		"gte":      validateOrder(func(c int) bool { return c >= 0 }),
+		"lt":       validateOrder(func(c int) bool { return c < 0 }),
+		"lte":      validateOrder(func(c int) bool { return c <= 0 }),
+		"eqfield":  validateEqField,
+	}
+
+	rulesCache  sync.Map // tag -> []validationRule
+	regexpCache sync.Map // pattern -> *regexp.Regexp
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// RegisterValidation adds a rule usable in `validate:` tags, replacing any
+// rule with the same name.
+func RegisterValidation(name string, fn ValidationFunc) {
+	validationsMu.Lock()
+	defer validationsMu.Unlock()
+	validations[name] = fn
+}
+
+func (fe FieldError) Error() string {
+	if fe.Param != "" {
+		return fmt.Sprintf("%s failed on the %s=%s rule", fe.Field, fe.Rule, fe.Param)
+	}
+	return fmt.Sprintf("%s failed on the %s rule", fe.Field, fe.Rule)
+}
+
+// Message describes the failed rule in words fit to show next to the input.
+// Rules added with RegisterValidation get a generic message.
+func (fe FieldError) Message() string {
+	switch fe.Rule {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param
+	case "max":
+		return "must be at most " + fe.Param
+	case "len":
+		return "must have a length of " + fe.Param
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param), ", ")
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "regexp":
+		return "has an invalid format"
+	case "uuid":
+		return "must be a valid UUID"
+	case "gt":
+		return "must be greater than " + fe.Param
+	case "gte":
+		return "must be greater than or equal to " + fe.Param
+	case "lt":
+		return "must be less than " + fe.Param
+	case "lte":
+		return "must be less than or equal to " + fe.Param
+	case "eqfield":
+		return "must match " + fe.Param
+	}
+	return "is invalid"
+}
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, fe := range ve {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (ve ValidationErrors) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// Validate checks v, a struct or a pointer to one, against the rules in its
+// `validate:` tags. Rules are separated by commas and take a parameter after
+// "=", as in `validate:"required,min=3,max=20"`. A regexp rule takes the rest
+// of the tag, so it must come last. Nested structs, and slices and maps of
+// structs, are validated too. It returns ValidationErrors when any rule fails.
+//
+// Fields that are nil pointers, or zero with the omitempty rule, skip every
+// rule but required.
+func Validate(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected a struct, got %s", rv.Kind())
+	}
+
+	var errs ValidationErrors
+	if err := validateStruct(rv, "", "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Validate runs Validate on v.
+func (c *CTX) Validate(v any) error {
+	return Validate(v)
+}
+
+func validateStruct(rv reflect.Value, fieldPrefix, keyPrefix string, errs *ValidationErrors) error {
+	typ := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		field := rv.Field(i)
+		fieldPath, keyPath := fieldPrefix, keyPrefix
+		if !sf.Anonymous {
+			fieldPath = joinPath(fieldPrefix, sf.Name)
+			keyPath = joinPath(keyPrefix, fieldKey(sf))
+		}
+
+		if err := validateField(field, rv, sf.Tag.Get("validate"), fieldPath, keyPath, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateField(field, parent reflect.Value, tag, fieldPath, keyPath string, errs *ValidationErrors) error {
+	rules, err := parseRules(tag)
+	if err != nil {
+		return err
+	}
+
+	value := field
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	absent := value.Kind() == reflect.Ptr || (hasRule(rules, "omitempty") && value.IsZero())
+
+	for _, rule := range rules {
+		if rule.name == "omitempty" || (absent && rule.name != "required") {
+			continue
+		}
+
+		validationsMu.RLock()
+		fn, ok := validations[rule.name]
+		validationsMu.RUnlock()
+		if !ok {
+			return fmt.Errorf("validate: unknown rule %q on %s", rule.name, fieldPath)
+		}
+
+		if !fn(ValidationField{Value: value, Parent: parent, Param: rule.param}) {
+			var iface any
+			if value.IsValid() && value.CanInterface() {
+				iface = value.Interface()
+			}
+			*errs = append(*errs, FieldError{
+				Field: fieldPath,
+				Key:   keyPath,
+				Rule:  rule.name,
+				Param: rule.param,
+				Value: iface,
+			})
+		}
+	}
+
+	if absent {
+		return nil
+	}
+	return validateNested(value, fieldPath, keyPath, errs)
+}
+
+// validateNested descends into structs and into slices, arrays and maps
+// holding them.
+func validateNested(value reflect.Value, fieldPath, keyPath string, errs *ValidationErrors) error {
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return nil
+		}
+		return validateStruct(value, fieldPath, keyPath, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			index := "[" + strconv.Itoa(i) + "]"
+			if err := validateNested(derefValue(value.Index(i)), fieldPath+index, keyPath+index, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			index := "[" + fmt.Sprint(iter.Key().Interface()) + "]"
+			if err := validateNested(derefValue(iter.Value()), fieldPath+index, keyPath+index, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func parseRules(tag string) ([]validationRule, error) {
+	if tag == "" || tag == "-" {
+		return nil, nil
+	}
+	if cached, ok := rulesCache.Load(tag); ok {
+		return cached.([]validationRule), nil
+	}
+
+	var rules []validationRule
+	rest := tag
+	for rest != "" {
+		var part string
+		rest = strings.TrimLeft(rest, " ")
+		if strings.HasPrefix(rest, "regexp=") {
+			part, rest = rest, ""
+		} else {
+			part, rest, _ = strings.Cut(rest, ",")
+		}
+
+		name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		if name == "regexp" {
+			if _, err := compileRegexp(param); err != nil {
+				return nil, fmt.Errorf("validate: %w", err)
+			}
+		}
+		rules = append(rules, validationRule{name: name, param: param})
+	}
+
+	rulesCache.Store(tag, rules)
+	return rules, nil
+}
+
+func hasRule(rules []validationRule, name string) bool {
+	for _, rule := range rules {
+		if rule.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldKey(sf reflect.StructField) string {
+	for _, tag := range []string{"form", "json"} {
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func derefValue(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexpCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexpCache.Store(pattern, re)
+	return re, nil
+}
+
+func validateRequired(f ValidationField) bool {
+	v := f.Value
+	switch v.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Ptr, reflect.Interface:
+		return !v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() > 0
+	}
+	return !v.IsZero()
+}
+
+// size returns the measure compared by min, max and len: the number of
+// characters of a string, the length of a collection or the number itself.
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// sizeParam parses a rule parameter, accepting durations for time.Duration
+// fields.
+func sizeParam(v reflect.Value, param string) (float64, bool) {
+	if v.Type() == durationType {
+		if d, err := time.ParseDuration(param); err == nil {
+			return float64(d), true
+		}
+	}
+	n, err := strconv.ParseFloat(param, 64)
+	return n, err == nil
+}
+
+func validateMin(f ValidationField) bool {
+	n, ok := size(f.Value)
+	limit, pok := sizeParam(f.Value, f.Param)
+	return ok && pok && n >= limit
+}
+
+func validateMax(f ValidationField) bool {
+	n, ok := size(f.Value)
+	limit, pok := sizeParam(f.Value, f.Param)
+	return ok && pok && n <= limit
+}
+
+func validateLen(f ValidationField) bool {
+	n, ok := size(f.Value)
+	l, pok := sizeParam(f.Value, f.Param)
+	return ok && pok && n == l
+}
+
+func validateOneOf(f ValidationField) bool {
+	if !f.Value.IsValid() {
+		return false
+	}
+	value := fmt.Sprint(f.Value.Interface())
+	for _, option := range strings.Fields(f.Param) {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+func validateEmail(f ValidationField) bool {
+	if f.Value.Kind() != reflect.String {
+		return false
+	}
+	addr, err := mail.ParseAddress(f.Value.String())
+	return err == nil && addr.Address == f.Value.String()
+}
+
+func validateURL(f ValidationField) bool {
+	if f.Value.Kind() != reflect.String {
+		return false
+	}
+	u, err := url.Parse(f.Value.String())
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func validateRegexp(f ValidationField) bool {
+	re, err := compileRegexp(f.Param)
+	return err == nil && f.Value.Kind() == reflect.String && re.MatchString(f.Value.String())
+}
+
+func validateUUID(f ValidationField) bool {
+	return f.Value.Kind() == reflect.String && uuidPattern.MatchString(f.Value.String())
+}
+
+// validateOrder builds gt, gte, lt and lte. Numbers are compared with the
+// parameter as a number, times with the parameter as RFC 3339 or "now".
+func validateOrder(accept func(cmp int) bool) ValidationFunc {
+	return func(f ValidationField) bool {
+		if f.Value.Type() == timeType {
+			t := f.Value.Interface().(time.Time)
+			param := time.Now()
+			if f.Param != "now" {
+				var err error
+				if param, err = time.Parse(time.RFC3339, f.Param); err != nil {
+					return false
+				}
+			}
+			return accept(t.Compare(param))
+		}
+
+		n, ok := size(f.Value)
+		param, pok := sizeParam(f.Value, f.Param)
+		if !ok || !pok {
+			return false
+		}
+		switch {
+		case n < param:
+			return accept(-1)
+		case n > param:
+			return accept(1)
+		}
+		return accept(0)
+	}
+}
+
+func validateEqField(f ValidationField) bool {
+	if f.Parent.Kind() != reflect.Struct {
+		return false
+	}
+	other := derefValue(f.Parent.FieldByName(f.Param))
+	if !other.IsValid() || !f.Value.IsValid() {
+		return false
+	}
+	if f.Value.Type() == timeType && other.Type() == timeType {
+		return f.Value.Interface().(time.Time).Equal(other.Interface().(time.Time))
+	}
+	return reflect.DeepEqual(f.Value.Interface(), other.Interface())
+}
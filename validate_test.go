@@ -0,0 +1,171 @@
+package ron
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type SignUp struct {
+	Name     string        `form:"name" validate:"required,min=3,max=10"`
+	Email    string        `form:"email" validate:"required,email"`
+	Website  string        `form:"website" validate:"omitempty,url"`
+	Code     string        `json:"code" validate:"len=4"`
+	Role     string        `validate:"oneof=admin user"`
+	Slug     string        `validate:"regexp=^[a-z0-9-]+$"`
+	ID       string        `validate:"uuid"`
+	Age      int           `validate:"gte=18,lte=130"`
+	Score    float64       `validate:"gt=0,lt=1"`
+	Tags     []string      `validate:"min=1,max=3"`
+	Password string        `validate:"required"`
+	Confirm  string        `validate:"eqfield=Password"`
+	Birthday time.Time     `validate:"lt=now"`
+	Timeout  time.Duration `validate:"max=1m"`
+	Nickname *string       `validate:"min=2"`
+}
+
+func validSignUp() SignUp {
+	return SignUp{
+		Name:     "foo",
+		Email:    "foo@example.com",
+		Code:     "ABCD",
+		Role:     "user",
+		Slug:     "foo-bar",
+		ID:       "123e4567-e89b-12d3-a456-426614174000",
+		Age:      30,
+		Score:    0.5,
+		Tags:     []string{"a"},
+		Password: "secret",
+		Confirm:  "secret",
+		Birthday: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		Timeout:  time.Second,
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	tests := map[string]struct {
+		modify   func(*SignUp)
+		expected []string
+	}{
+		"valid":             {modify: func(s *SignUp) {}},
+		"required":          {modify: func(s *SignUp) { s.Name = "" }, expected: []string{"Name:required", "Name:min"}},
+		"min":               {modify: func(s *SignUp) { s.Name = "fo" }, expected: []string{"Name:min"}},
+		"max":               {modify: func(s *SignUp) { s.Name = "foobarbazqux" }, expected: []string{"Name:max"}},
+		"email":             {modify: func(s *SignUp) { s.Email = "Foo <foo@example.com>" }, expected: []string{"Email:email"}},
+		"omitempty skipped": {modify: func(s *SignUp) { s.Website = "" }},
+		"url":               {modify: func(s *SignUp) { s.Website = "example.com" }, expected: []string{"Website:url"}},
+		"len":               {modify: func(s *SignUp) { s.Code = "ABC" }, expected: []string{"Code:len"}},
+		"oneof":             {modify: func(s *SignUp) { s.Role = "root" }, expected: []string{"Role:oneof"}},
+		"regexp":            {modify: func(s *SignUp) { s.Slug = "Foo Bar" }, expected: []string{"Slug:regexp"}},
+		"uuid":              {modify: func(s *SignUp) { s.ID = "123" }, expected: []string{"ID:uuid"}},
+		"gte":               {modify: func(s *SignUp) { s.Age = 17 }, expected: []string{"Age:gte"}},
+		"lte":               {modify: func(s *SignUp) { s.Age = 131 }, expected: []string{"Age:lte"}},
+		"gt and lt":         {modify: func(s *SignUp) { s.Score = 1 }, expected: []string{"Score:lt"}},
+		"slice length":      {modify: func(s *SignUp) { s.Tags = nil }, expected: []string{"Tags:min"}},
+		"eqfield":           {modify: func(s *SignUp) { s.Confirm = "other" }, expected: []string{"Confirm:eqfield"}},
+		"time":              {modify: func(s *SignUp) { s.Birthday = time.Now().Add(time.Hour) }, expected: []string{"Birthday:lt"}},
+		"duration":          {modify: func(s *SignUp) { s.Timeout = time.Hour }, expected: []string{"Timeout:max"}},
+		"nil pointer":       {modify: func(s *SignUp) { s.Nickname = nil }},
+		"pointer": {
+			modify:   func(s *SignUp) { nick := "x"; s.Nickname = &nick },
+			expected: []string{"Nickname:min"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := validSignUp()
+			tt.modify(&s)
+
+			err := Validate(&s)
+			if len(tt.expected) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, Actual: %v", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Expected ValidationErrors, Actual: %v", err)
+			}
+			var actual []string
+			for _, fe := range errs {
+				actual = append(actual, fe.Field+":"+fe.Rule)
+			}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("Expected: %v, Actual: %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_ValidateNested(t *testing.T) {
+	RegisterValidation("positive", func(f ValidationField) bool {
+		return f.Value.Int() > 0
+	})
+	type item struct {
+		Qty int `form:"qty" validate:"positive"`
+	}
+	type order struct {
+		Items []item `form:"items"`
+		Owner struct {
+			Email string `json:"email" validate:"email"`
+		} `json:"owner"`
+	}
+
+	o := order{Items: []item{{Qty: 1}, {Qty: 0}}}
+	o.Owner.Email = "nope"
+
+	expected := ValidationErrors{
+		{Field: "Items[1].Qty", Key: "items[1].qty", Rule: "positive", Value: 0},
+		{Field: "Owner.Email", Key: "owner.email", Rule: "email", Value: "nope"},
+	}
+	err := Validate(o)
+	if !reflect.DeepEqual(error(expected), err) {
+		t.Errorf("Expected: %v, Actual: %v", expected, err)
+	}
+	if code := StatusCode(err); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code: %d, Actual: %d", http.StatusUnprocessableEntity, code)
+	}
+}
+
+func Test_ValidateUnknownRule(t *testing.T) {
+	type unknown struct {
+		Name string `validate:"shiny"`
+	}
+	err := Validate(unknown{})
+	if err == nil || !strings.Contains(err.Error(), `unknown rule "shiny"`) {
+		t.Errorf("Expected unknown rule error, Actual: %v", err)
+	}
+}
+
+func Test_BindValidate(t *testing.T) {
+	tests := map[string]struct {
+		validate bool
+		wantErr  bool
+	}{
+		"validation disabled": {validate: false, wantErr: false},
+		"validation enabled":  {validate: true, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?name=fo", nil)
+			c := &CTX{R: req, E: New(func(e *Engine) {
+				e.Config.Binding.Validate = tt.validate
+			})}
+
+			var s struct {
+				Name string `query:"name" validate:"min=3"`
+			}
+			if err := c.BindQuery(&s); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, Actual: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
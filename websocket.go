@@ -0,0 +1,644 @@
+package ron
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type (
+	WebSocketOptions func(*WebSocketConfig)
+
+	WebSocketConfig struct {
+		// CheckOrigin reports whether the handshake may proceed for the
+		// request's Origin. By default only same-host origins, or requests
+		// without an Origin header, are accepted.
+		CheckOrigin func(r *http.Request) bool
+		// Subprotocols lists the subprotocols supported by the server in
+		// order of preference.
+		Subprotocols []string
+		// ReadLimit is the maximum size in bytes of a message, after
+		// reassembling fragments and decompressing.
+		ReadLimit int64
+		// FragmentSize splits outgoing messages into frames of at most this
+		// many bytes. Zero sends every message as a single frame.
+		FragmentSize int
+		// EnableCompression negotiates permessage-deflate when the client
+		// offers it.
+		EnableCompression bool
+		// CompressionLevel is the compress/flate level used for outgoing
+		// messages.
+		CompressionLevel int
+	}
+
+	// WebSocketConn is an established WebSocket connection. It supports one
+	// concurrent reader and any number of concurrent writers.
+	WebSocketConn struct {
+		conn         net.Conn
+		br           *bufio.Reader
+		subprotocol  string
+		readLimit    int64
+		fragmentSize int
+		compress     bool
+		level        int
+
+		writeMu   sync.Mutex
+		closeSent bool
+
+		pingHandler func(data []byte) error
+		pongHandler func(data []byte) error
+	}
+
+	// CloseError is returned by ReadMessage once the connection has been
+	// closed by the peer or because of a protocol violation.
+	CloseError struct {
+		Code int
+		Text string
+	}
+)
+
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	ErrWebSocketCloseSent = errors.New("websocket: close frame already sent")
+
+	// deflateTail is the empty stored block that ends a flushed deflate
+	// stream. permessage-deflate strips it from every message.
+	deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+)
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+func defaultWebSocketConfig() *WebSocketConfig {
+	return &WebSocketConfig{
+		CheckOrigin:      sameOrigin,
+		ReadLimit:        1 << 20,
+		CompressionLevel: flate.BestSpeed,
+	}
+}
+
+func (wc *WebSocketConfig) apply(opts ...WebSocketOptions) *WebSocketConfig {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(wc)
+		}
+	}
+
+	return wc
+}
+
+// Upgrade performs the WebSocket handshake and takes over the connection.
+// When the handshake fails an error response has already been written and
+// the handler should simply return. Mark the route Streaming so
+// TimeOutMiddleware doesn't cut the connection short.
+func (c *CTX) Upgrade(opts ...WebSocketOptions) (*WebSocketConn, error) {
+	config := defaultWebSocketConfig().apply(opts...)
+	r := c.R
+
+	if r.Method != http.MethodGet {
+		return nil, c.websocketError(http.StatusMethodNotAllowed, "websocket: method must be GET")
+	}
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		return nil, c.websocketError(http.StatusBadRequest, "websocket: not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		c.W.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, c.websocketError(http.StatusUpgradeRequired, "websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, c.websocketError(http.StatusBadRequest, "websocket: invalid Sec-WebSocket-Key")
+	}
+	if config.CheckOrigin != nil && !config.CheckOrigin(r) {
+		return nil, c.websocketError(http.StatusForbidden, "websocket: origin not allowed")
+	}
+
+	subprotocol := selectSubprotocol(r, config.Subprotocols)
+	compress := config.EnableCompression && offersDeflate(r)
+
+	conn, brw, err := http.NewResponseController(c.W).Hijack()
+	if err != nil {
+		return nil, c.websocketError(http.StatusInternalServerError, "websocket: "+err.Error())
+	}
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	b.WriteString("Upgrade: websocket\r\n")
+	b.WriteString("Connection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + websocketAccept(key) + "\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		b.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	b.WriteString("\r\n")
+
+	conn.SetDeadline(time.Time{})
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ws := &WebSocketConn{
+		conn:         conn,
+		br:           brw.Reader,
+		subprotocol:  subprotocol,
+		readLimit:    config.ReadLimit,
+		fragmentSize: config.FragmentSize,
+		compress:     compress,
+		level:        config.CompressionLevel,
+	}
+	ws.pingHandler = func(data []byte) error {
+		return ws.writeControl(PongMessage, data)
+	}
+	ws.pongHandler = func([]byte) error { return nil }
+
+	return ws, nil
+}
+
+func (c *CTX) websocketError(code int, msg string) error {
+	http.Error(c.W, http.StatusText(code), code)
+	return errors.New(msg)
+}
+
+// Subprotocol returns the subprotocol agreed during the handshake.
+func (ws *WebSocketConn) Subprotocol() string {
+	return ws.subprotocol
+}
+
+func (ws *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return ws.conn.SetReadDeadline(t)
+}
+
+func (ws *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	return ws.conn.SetWriteDeadline(t)
+}
+
+// SetPingHandler replaces the default handler, which answers with a pong.
+func (ws *WebSocketConn) SetPingHandler(h func(data []byte) error) {
+	ws.pingHandler = h
+}
+
+func (ws *WebSocketConn) SetPongHandler(h func(data []byte) error) {
+	ws.pongHandler = h
+}
+
+// ReadMessage returns the next text or binary message, reassembling
+// fragments and answering control frames on the way. After the peer closes
+// the connection, or on a protocol violation, it returns a *CloseError.
+func (ws *WebSocketConn) ReadMessage() (messageType int, p []byte, err error) {
+	var (
+		message    []byte
+		compressed bool
+	)
+
+	for {
+		f, err := ws.readFrame(ws.readLimit - int64(len(message)))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch f.opcode {
+		case PingMessage:
+			if err := ws.pingHandler(f.payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if err := ws.pongHandler(f.payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, ws.handleClose(f.payload)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, ws.fail(CloseProtocolError, "expected continuation frame")
+			}
+			if f.rsv1 && !ws.compress {
+				return 0, nil, ws.fail(CloseProtocolError, "unexpected compressed frame")
+			}
+			messageType, compressed = f.opcode, f.rsv1
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, ws.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+			if f.rsv1 {
+				return 0, nil, ws.fail(CloseProtocolError, "unexpected RSV1 on continuation frame")
+			}
+		default:
+			return 0, nil, ws.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		message = append(message, f.payload...)
+		if !f.fin {
+			continue
+		}
+
+		if compressed {
+			if message, err = ws.inflate(message); err != nil {
+				return 0, nil, err
+			}
+		}
+		if messageType == TextMessage && !utf8.Valid(message) {
+			return 0, nil, ws.fail(CloseInvalidFramePayloadData, "invalid UTF-8 in text message")
+		}
+
+		return messageType, message, nil
+	}
+}
+
+// ReadJSON reads the next message and decodes it as JSON into v.
+func (ws *WebSocketConn) ReadJSON(v any) error {
+	_, p, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(p, v)
+}
+
+// WriteMessage sends a message. Text and binary messages are compressed and
+// fragmented according to the connection settings; control messages are
+// sent as a single frame.
+func (ws *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case CloseMessage, PingMessage, PongMessage:
+		return ws.writeControl(messageType, data)
+	default:
+		return fmt.Errorf("websocket: unknown message type %d", messageType)
+	}
+
+	rsv1 := false
+	if ws.compress {
+		var err error
+		if data, err = ws.deflate(data); err != nil {
+			return err
+		}
+		rsv1 = true
+	}
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	if ws.closeSent {
+		return ErrWebSocketCloseSent
+	}
+
+	opcode := messageType
+	for {
+		chunk := data
+		if ws.fragmentSize > 0 && len(chunk) > ws.fragmentSize {
+			chunk = chunk[:ws.fragmentSize]
+		}
+		data = data[len(chunk):]
+
+		if err := ws.writeFrame(len(data) == 0, rsv1, opcode, chunk); err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		opcode, rsv1 = continuationFrame, false
+	}
+}
+
+// WriteJSON encodes v as JSON and sends it as a text message.
+func (ws *WebSocketConn) WriteJSON(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(TextMessage, b)
+}
+
+func (ws *WebSocketConn) Ping(data []byte) error {
+	return ws.writeControl(PingMessage, data)
+}
+
+// Close sends a close frame with the given code and reason, then closes the
+// underlying connection.
+func (ws *WebSocketConn) Close(code int, reason string) error {
+	err := ws.writeControl(CloseMessage, closePayload(code, reason))
+	if errors.Is(err, ErrWebSocketCloseSent) {
+		err = nil
+	}
+	if cerr := ws.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+type websocketFrame struct {
+	fin     bool
+	rsv1    bool
+	opcode  int
+	payload []byte
+}
+
+func (ws *WebSocketConn) readFrame(limit int64) (websocketFrame, error) {
+	var f websocketFrame
+	var header [8]byte
+
+	if _, err := io.ReadFull(ws.br, header[:2]); err != nil {
+		return f, err
+	}
+
+	f.fin = header[0]&0x80 != 0
+	f.rsv1 = header[0]&0x40 != 0
+	f.opcode = int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7f)
+
+	if header[0]&0x30 != 0 {
+		return f, ws.fail(CloseProtocolError, "reserved bits set")
+	}
+	if !masked {
+		return f, ws.fail(CloseProtocolError, "client frames must be masked")
+	}
+
+	switch length {
+	case 126:
+		if _, err := io.ReadFull(ws.br, header[:2]); err != nil {
+			return f, err
+		}
+		length = int64(binary.BigEndian.Uint16(header[:2]))
+	case 127:
+		if _, err := io.ReadFull(ws.br, header[:8]); err != nil {
+			return f, err
+		}
+		if header[0]&0x80 != 0 {
+			return f, ws.fail(CloseProtocolError, "invalid payload length")
+		}
+		length = int64(binary.BigEndian.Uint64(header[:8]))
+	}
+
+	if f.opcode >= CloseMessage {
+		if !f.fin || length > 125 || f.rsv1 {
+			return f, ws.fail(CloseProtocolError, "invalid control frame")
+		}
+	} else if length > limit {
+		return f, ws.fail(CloseMessageTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.br, mask[:]); err != nil {
+		return f, err
+	}
+
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(ws.br, f.payload); err != nil {
+		return f, err
+	}
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+
+	return f, nil
+}
+
+func (ws *WebSocketConn) writeFrame(fin, rsv1 bool, opcode int, payload []byte) error {
+	header := make([]byte, 2, 10+len(payload))
+	header[0] = byte(opcode)
+	if fin {
+		header[0] |= 0x80
+	}
+	if rsv1 {
+		header[0] |= 0x40
+	}
+
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	_, err := ws.conn.Write(append(header, payload...))
+	return err
+}
+
+func (ws *WebSocketConn) writeControl(opcode int, payload []byte) error {
+	if len(payload) > 125 {
+		return errors.New("websocket: control frame payload too long")
+	}
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	if ws.closeSent {
+		return ErrWebSocketCloseSent
+	}
+	if opcode == CloseMessage {
+		ws.closeSent = true
+	}
+
+	return ws.writeFrame(true, false, opcode, payload)
+}
+
+// handleClose answers a close frame from the peer and shuts the connection.
+func (ws *WebSocketConn) handleClose(payload []byte) error {
+	code, reason := CloseNoStatusReceived, ""
+
+	switch {
+	case len(payload) == 1:
+		return ws.fail(CloseProtocolError, "invalid close payload")
+	case len(payload) >= 2:
+		code = int(binary.BigEndian.Uint16(payload))
+		reason = string(payload[2:])
+		if !validCloseCode(code) {
+			return ws.fail(CloseProtocolError, "invalid close code")
+		}
+		if !utf8.ValidString(reason) {
+			return ws.fail(CloseInvalidFramePayloadData, "invalid UTF-8 in close reason")
+		}
+	}
+
+	echo := []byte{}
+	if code != CloseNoStatusReceived {
+		echo = closePayload(code, "")
+	}
+	ws.writeControl(CloseMessage, echo)
+	ws.conn.Close()
+
+	return &CloseError{Code: code, Text: reason}
+}
+
+// fail closes the connection because of an error on our side of the
+// protocol and returns the matching *CloseError.
+func (ws *WebSocketConn) fail(code int, text string) error {
+	ws.writeControl(CloseMessage, closePayload(code, text))
+	ws.conn.Close()
+	return &CloseError{Code: code, Text: text}
+}
+
+func (ws *WebSocketConn) deflate(p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, ws.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+func (ws *WebSocketConn) inflate(p []byte) ([]byte, error) {
+	// Terminate the stream with the stripped tail and a final empty block
+	// so the reader reports io.EOF instead of io.ErrUnexpectedEOF.
+	tail := append(append([]byte{}, deflateTail...), 0x01, 0x00, 0x00, 0xff, 0xff)
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(p), bytes.NewReader(tail)))
+	defer fr.Close()
+
+	b, err := io.ReadAll(io.LimitReader(fr, ws.readLimit+1))
+	if err != nil {
+		return nil, ws.fail(CloseInvalidFramePayloadData, "invalid compressed data")
+	}
+	if int64(len(b)) > ws.readLimit {
+		return nil, ws.fail(CloseMessageTooBig, "message too big")
+	}
+	return b, nil
+}
+
+func closePayload(code int, reason string) []byte {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	}
+	return false
+}
+
+func websocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	for _, offered := range headerTokens(r.Header, "Sec-WebSocket-Protocol") {
+		for _, s := range supported {
+			if offered == s {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// offersDeflate reports whether the client offers permessage-deflate with
+// parameters we can honour. compress/flate always uses a 32KB window, so an
+// offer restricting the server window is declined.
+func offersDeflate(r *http.Request) bool {
+	for _, ext := range headerTokens(r.Header, "Sec-WebSocket-Extensions") {
+		params := strings.Split(ext, ";")
+		if strings.TrimSpace(params[0]) != "permessage-deflate" {
+			continue
+		}
+
+		ok := true
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			switch name {
+			case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+			case "server_max_window_bits":
+				ok = ok && strings.Trim(value, `"`) == "15"
+			default:
+				ok = false
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// headerTokens splits every value of a comma separated header.
+func headerTokens(h http.Header, name string) []string {
+	var tokens []string
+	for _, v := range h.Values(name) {
+		for _, token := range strings.Split(v, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, t := range headerTokens(h, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
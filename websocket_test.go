@@ -0,0 +1,294 @@
+package ron
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type wsClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+	resp *http.Response
+}
+
+func newWebSocketServer(t *testing.T, handlerErr chan<- error, opts ...WebSocketOptions) *httptest.Server {
+	t.Helper()
+	e := New()
+	e.USE(e.TimeOutMiddleware())
+	e.GET("/ws", func(c *CTX, ctx context.Context) {
+		ws, err := c.Upgrade(opts...)
+		if err != nil {
+			return
+		}
+		for {
+			mt, p, err := ws.ReadMessage()
+			if err != nil {
+				handlerErr <- err
+				return
+			}
+			ws.WriteMessage(mt, p)
+		}
+	}, func(r *Route) {
+		r.Streaming = true
+	})
+
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dialWebSocket(t *testing.T, srv *httptest.Server, header http.Header) *wsClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, _ := http.NewRequest("GET", srv.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatalf("ReadResponse() failed: %v", err)
+	}
+
+	return &wsClient{conn: conn, br: br, resp: resp}
+}
+
+func (c *wsClient) writeFrame(t *testing.T, fin, rsv1 bool, opcode int, payload []byte) {
+	t.Helper()
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	if rsv1 {
+		b0 |= 0x40
+	}
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = binary.BigEndian.AppendUint16(append(frame, 0x80|126), uint16(n))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, 0x80|127), uint64(n))
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+}
+
+func (c *wsClient) readFrame(t *testing.T) (fin, rsv1 bool, opcode int, payload []byte) {
+	t.Helper()
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		t.Fatalf("ReadFull() failed: %v", err)
+	}
+	if h[1]&0x80 != 0 {
+		t.Fatal("Expected unmasked server frame")
+	}
+	length := int(h[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		t.Fatalf("ReadFull() failed: %v", err)
+	}
+	return h[0]&0x80 != 0, h[0]&0x40 != 0, int(h[0] & 0x0f), payload
+}
+
+func Test_UpgradeHandshake(t *testing.T) {
+	srv := newWebSocketServer(t, make(chan error, 1), func(wc *WebSocketConfig) {
+		wc.Subprotocols = []string{"chat"}
+	})
+	c := dialWebSocket(t, srv, http.Header{"Sec-Websocket-Protocol": {"superchat, chat"}})
+
+	if c.resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status code: %d, Actual: %d", http.StatusSwitchingProtocols, c.resp.StatusCode)
+	}
+	if accept := c.resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Expected Sec-WebSocket-Accept: s3pPLMBiTxaQ9kYGzzhZRbK+xOo=, Actual: %s", accept)
+	}
+	if protocol := c.resp.Header.Get("Sec-WebSocket-Protocol"); protocol != "chat" {
+		t.Errorf("Expected Sec-WebSocket-Protocol: chat, Actual: %s", protocol)
+	}
+}
+
+func Test_UpgradeRejected(t *testing.T) {
+	tests := map[string]struct {
+		header       http.Header
+		expectedCode int
+	}{
+		"cross origin": {
+			header:       http.Header{"Origin": {"http://evil.example"}},
+			expectedCode: http.StatusForbidden,
+		},
+		"wrong version": {
+			header:       http.Header{"Sec-Websocket-Version": {"8"}},
+			expectedCode: http.StatusUpgradeRequired,
+		},
+		"invalid key": {
+			header:       http.Header{"Sec-Websocket-Key": {"short"}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	srv := newWebSocketServer(t, make(chan error, 1))
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := dialWebSocket(t, srv, tt.header)
+			if c.resp.StatusCode != tt.expectedCode {
+				t.Errorf("Expected status code: %d, Actual: %d", tt.expectedCode, c.resp.StatusCode)
+			}
+		})
+	}
+}
+
+func Test_WebSocketEcho(t *testing.T) {
+	srv := newWebSocketServer(t, make(chan error, 1))
+	c := dialWebSocket(t, srv, nil)
+
+	c.writeFrame(t, true, false, TextMessage, []byte("hello"))
+	fin, _, opcode, payload := c.readFrame(t)
+	if !fin || opcode != TextMessage || string(payload) != "hello" {
+		t.Errorf("Expected final text frame \"hello\", Actual: fin=%v opcode=%d payload=%q", fin, opcode, payload)
+	}
+}
+
+func Test_WebSocketFragmentsAndPing(t *testing.T) {
+	srv := newWebSocketServer(t, make(chan error, 1), func(wc *WebSocketConfig) {
+		wc.FragmentSize = 4
+	})
+	c := dialWebSocket(t, srv, nil)
+
+	c.writeFrame(t, false, false, BinaryMessage, []byte("frag"))
+	c.writeFrame(t, true, false, PingMessage, []byte("ping"))
+	c.writeFrame(t, true, false, continuationFrame, []byte("mented"))
+
+	_, _, opcode, payload := c.readFrame(t)
+	if opcode != PongMessage || string(payload) != "ping" {
+		t.Errorf("Expected pong \"ping\", Actual: opcode=%d payload=%q", opcode, payload)
+	}
+
+	var message []byte
+	expectedOpcodes := []int{BinaryMessage, continuationFrame, continuationFrame}
+	for i, expected := range expectedOpcodes {
+		fin, _, opcode, payload := c.readFrame(t)
+		if opcode != expected {
+			t.Errorf("Expected opcode: %d, Actual: %d", expected, opcode)
+		}
+		if fin != (i == len(expectedOpcodes)-1) {
+			t.Errorf("Unexpected fin bit on frame %d", i)
+		}
+		message = append(message, payload...)
+	}
+	if string(message) != "fragmented" {
+		t.Errorf("Expected: fragmented, Actual: %s", message)
+	}
+}
+
+func Test_WebSocketClose(t *testing.T) {
+	handlerErr := make(chan error, 1)
+	srv := newWebSocketServer(t, handlerErr)
+	c := dialWebSocket(t, srv, nil)
+
+	c.writeFrame(t, true, false, CloseMessage, closePayload(CloseGoingAway, "bye"))
+	_, _, opcode, payload := c.readFrame(t)
+	if opcode != CloseMessage || binary.BigEndian.Uint16(payload) != CloseGoingAway {
+		t.Errorf("Expected close %d, Actual: opcode=%d payload=%v", CloseGoingAway, opcode, payload)
+	}
+
+	var closeErr *CloseError
+	if err := <-handlerErr; !errors.As(err, &closeErr) || closeErr.Code != CloseGoingAway || closeErr.Text != "bye" {
+		t.Errorf("Expected CloseError %d bye, Actual: %v", CloseGoingAway, err)
+	}
+}
+
+func Test_WebSocketReadLimit(t *testing.T) {
+	srv := newWebSocketServer(t, make(chan error, 1), func(wc *WebSocketConfig) {
+		wc.ReadLimit = 8
+	})
+	c := dialWebSocket(t, srv, nil)
+
+	c.writeFrame(t, false, false, TextMessage, []byte("12345"))
+	c.writeFrame(t, true, false, continuationFrame, []byte("67890"))
+	_, _, opcode, payload := c.readFrame(t)
+	if opcode != CloseMessage || binary.BigEndian.Uint16(payload) != CloseMessageTooBig {
+		t.Errorf("Expected close %d, Actual: opcode=%d payload=%v", CloseMessageTooBig, opcode, payload)
+	}
+}
+
+func Test_WebSocketInvalidUTF8(t *testing.T) {
+	srv := newWebSocketServer(t, make(chan error, 1))
+	c := dialWebSocket(t, srv, nil)
+
+	c.writeFrame(t, true, false, TextMessage, []byte{0xff, 0xfe})
+	_, _, opcode, payload := c.readFrame(t)
+	if opcode != CloseMessage || binary.BigEndian.Uint16(payload) != CloseInvalidFramePayloadData {
+		t.Errorf("Expected close %d, Actual: opcode=%d payload=%v", CloseInvalidFramePayloadData, opcode, payload)
+	}
+}
+
+func Test_WebSocketCompression(t *testing.T) {
+	srv := newWebSocketServer(t, make(chan error, 1), func(wc *WebSocketConfig) {
+		wc.EnableCompression = true
+	})
+	c := dialWebSocket(t, srv, http.Header{"Sec-Websocket-Extensions": {"permessage-deflate; client_max_window_bits"}})
+
+	if ext := c.resp.Header.Get("Sec-WebSocket-Extensions"); !strings.HasPrefix(ext, "permessage-deflate") {
+		t.Fatalf("Expected permessage-deflate, Actual: %q", ext)
+	}
+
+	message := strings.Repeat("compress me ", 20)
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.BestCompression)
+	fw.Write([]byte(message))
+	fw.Flush()
+	c.writeFrame(t, true, true, TextMessage, bytes.TrimSuffix(buf.Bytes(), deflateTail))
+
+	_, rsv1, opcode, payload := c.readFrame(t)
+	if !rsv1 || opcode != TextMessage {
+		t.Fatalf("Expected compressed text frame, Actual: rsv1=%v opcode=%d", rsv1, opcode)
+	}
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(payload), bytes.NewReader(deflateTail)))
+	actual, _ := io.ReadAll(fr)
+	if string(actual) != message {
+		t.Errorf("Expected: %q, Actual: %q", message, actual)
+	}
+}